@@ -4,11 +4,16 @@
 package bot
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/18F/angrytock/helpers"
+	"github.com/18F/angrytock/leader"
 	"github.com/18F/angrytock/messages"
 	"github.com/18F/angrytock/safeDict"
 	"github.com/18F/angrytock/slack"
@@ -17,16 +22,89 @@ import (
 	"github.com/nlopes/slack"
 )
 
+// slackClient is the part of the slack api the bot uses to find users and
+// send messages
+type slackClient interface {
+	GetSelfID() string
+	FetchSlackUsers() []slack.User
+	SendToChannel(channelID string, message string)
+	MessageUser(user string, message string)
+	MessageChannel(channelID string, message string)
+	MessageUserEphemeral(channelID string, user string, message string)
+	ReactToMessage(channelID string, timestamp string, emoji string)
+	ReplyInThread(channelID string, threadTimestamp string, message string)
+}
+
 // Bot struct serves as the primary entry point for slack and tock api methods
 // It stores the slack token string and a database connection for storing
 // emails and usernames
 type Bot struct {
-	UserEmailMap    *safeDict.SafeDict
-	Slack           *slackPackage.Slack
-	Tock            *tockPackage.Tock
-	MessageRepo     *messagesPackage.MessageRepository
-	violatorUserMap *safeDict.SafeDict
+	UserEmailMap *safeDict.SafeDict[string, string]
+	Slack        slackClient
+	Tock         *tockPackage.Tock
+	MessageRepo  *messagesPackage.MessageStore
+	// messagesReload is how often the message files are checked for changes
+	messagesReload time.Duration
+	// violatorUserMap is the bother watchlist keyed by slack id
+	violatorUserMap *safeDict.SafeDict[string, botherEntry]
 	masterList      []string
+	// DigestSchedule is the cron spec for posting the late digest
+	DigestSchedule  string
+	digestChannels  []digestChannel
+	digestShowNames bool
+	// supervisor notification mode
+	supervisorNotifications   bool
+	supervisorEscalationDelay time.Duration
+	supervisorNotified        *safeDict.SafeDict[string, string]
+	// remindedUsers maps the slack ids of reminded users to the reporting period
+	remindedUsers *safeDict.SafeDict[string, string]
+	// userLocales maps slack ids to slack locales and localePreferences maps
+	// slack ids to languages users chose themselves
+	userLocales       *safeDict.SafeDict[string, string]
+	localePreferences *safeDict.SafeDict[string, string]
+	// defaultTone is used unless a channel or user chose a tone. channelTones
+	// and userTones map channel and user ids to tone names
+	defaultTone  string
+	channelTones *safeDict.SafeDict[string, string]
+	userTones    *safeDict.SafeDict[string, string]
+	// defaultNudgeStyle is how late users are bothered unless an admin chose
+	// a style for the channel. channelNudgeStyles maps channel ids to styles
+	defaultNudgeStyle  string
+	channelNudgeStyles *safeDict.SafeDict[string, string]
+	// pausedUsers holds the users who paused reminders and nudges until
+	// their entries expire
+	pausedUsers *safeDict.SafeDict[string, string]
+	// userTimezones maps slack ids to slack time zones and personalReminders
+	// maps slack ids to the reminders users asked for
+	userTimezones     *safeDict.SafeDict[string, string]
+	personalReminders *safeDict.SafeDict[string, []personalReminder]
+	// allowedChannels limits the channels the bot speaks in if it isn't
+	// empty and the bot never speaks in deniedChannels
+	allowedChannels map[string]bool
+	deniedChannels  map[string]bool
+	// connected is true while the real time connection to slack is up and
+	// the tock check fields cache the last tock reachability check
+	connected      atomic.Bool
+	tockCheckMutex sync.Mutex
+	tockCheckedAt  time.Time
+	tockCheckErr   error
+	// inFlight tracks background work such as reminder sends so it can
+	// finish before the bot stops. stopping is set once the bot is stopping.
+	inFlight      sync.WaitGroup
+	lifecycleLock sync.Mutex
+	stopping      bool
+	// stateDir is where the dictionaries are saved between restarts
+	stateDir         string
+	snapshotInterval time.Duration
+	// rtm is the real time connection to slack that lead starts and listen
+	// reads events from
+	rtm rtmConnection
+	// lease elects the instance that talks to slack when several run. The
+	// bot always leads if it is nil.
+	lease   *leader.Lease
+	leading atomic.Bool
+	// reminderLog keeps the most recent reminder runs for the dashboard
+	reminderLog reminderLog
 }
 
 // InitBot method initalizes a bot
@@ -34,14 +112,117 @@ func InitBot() *Bot {
 	appEnv, _ := cfenv.Current()
 	appService, _ := appEnv.Services.WithName("angrytock-credentials")
 
-	userEmailMap := safeDict.InitSafeDict()
-	violatorUserMap := safeDict.InitSafeDict()
+	userEmailMap := safeDict.InitSafeDict[string, string]()
+	violatorUserMap := safeDict.InitSafeDict[string, botherEntry]()
+	violatorUserMap.OnExpire(func(userID string, entry botherEntry) {
+		log.Printf("Stopped bothering %s", entry.Email)
+	})
+	violatorUserMap.StartSweeper(time.Minute)
 	masterList := strings.Split(fmt.Sprint(appService.Credentials["MASTER_LIST"]), ",")
 	slack := slackPackage.InitSlack()
 	tock := tockPackage.InitTock()
-	messageRepo := messagesPackage.InitMessageRepository()
+	messageRepo := messagesPackage.InitMessageStore(
+		splitList(helpers.FetchCredential("MESSAGE_FILES")),
+	)
+
+	digestSchedule := helpers.FetchCredential("DIGEST_SCHEDULE")
+	if digestSchedule == "" {
+		digestSchedule = "0 0 10 * * MON"
+	}
+
+	supervisorEscalationDelay := durationSetting("SUPERVISOR_ESCALATION_DELAY", 48*time.Hour)
+
+	defaultTone := helpers.FetchCredential("DEFAULT_TONE")
+	if defaultTone == "" {
+		defaultTone = "snarky"
+	}
+
+	defaultNudgeStyle := strings.ToLower(helpers.FetchCredential("NUDGE_STYLE"))
+	if !isNudgeStyle(defaultNudgeStyle) {
+		defaultNudgeStyle = "message"
+	}
+
+	var lease *leader.Lease
+	if leaseFile := helpers.FetchCredential("LEADER_LEASE_FILE"); leaseFile != "" {
+		leaseDuration := durationSetting("LEADER_LEASE_DURATION", 30*time.Second)
+		if leaseDuration < minLeaseDuration {
+			log.Printf("LEADER_LEASE_DURATION is too short, using %s", minLeaseDuration)
+			leaseDuration = minLeaseDuration
+		}
+		lease = leader.NewLease(leaseFile, leader.DefaultHolder(), leaseDuration)
+	}
+
+	bot := &Bot{
+		UserEmailMap:    userEmailMap,
+		Slack:           slack,
+		rtm:             slack,
+		Tock:            tock,
+		MessageRepo:     messageRepo,
+		messagesReload:  durationSetting("MESSAGES_RELOAD_INTERVAL", time.Minute),
+		violatorUserMap: violatorUserMap,
+		masterList:      masterList,
+		DigestSchedule:  digestSchedule,
+		digestChannels:  parseDigestChannels(helpers.FetchCredential("DIGEST_CHANNELS")),
+		digestShowNames: helpers.FetchCredential("DIGEST_SHOW_NAMES") == "true",
+
+		supervisorNotifications:   helpers.FetchCredential("SUPERVISOR_NOTIFICATIONS") == "true",
+		supervisorEscalationDelay: supervisorEscalationDelay,
+		supervisorNotified:        safeDict.InitSafeDict[string, string](),
+		remindedUsers:             safeDict.InitSafeDict[string, string](),
+		userLocales:               safeDict.InitSafeDict[string, string](),
+		localePreferences:         safeDict.InitSafeDict[string, string](),
+		defaultTone:               defaultTone,
+		channelTones:              safeDict.InitSafeDict[string, string](),
+		userTones:                 safeDict.InitSafeDict[string, string](),
+		defaultNudgeStyle:         defaultNudgeStyle,
+		channelNudgeStyles:        safeDict.InitSafeDict[string, string](),
+		pausedUsers:               safeDict.InitSafeDict[string, string](),
+		userTimezones:             safeDict.InitSafeDict[string, string](),
+		personalReminders:         safeDict.InitSafeDict[string, []personalReminder](),
+		allowedChannels:           channelSet(splitList(helpers.FetchCredential("ALLOWED_CHANNELS"))),
+		deniedChannels:            channelSet(splitList(helpers.FetchCredential("DENIED_CHANNELS"))),
+		stateDir:                  helpers.FetchCredential("STATE_DIR"),
+		snapshotInterval:          durationSetting("STATE_SNAPSHOT_INTERVAL", 5*time.Minute),
+		lease:                     lease,
+	}
+	if lease != nil {
+		bot.Slack = leaderSlack{slackClient: slack, bot: bot}
+	}
+	bot.restoreState()
+	bot.registerMetrics()
+	return bot
+}
+
+// durationSetting reads a duration setting such as `48h`. Missing or invalid
+// values use the fallback and invalid values are logged. Durations must be
+// positive.
+func durationSetting(name string, fallback time.Duration) time.Duration {
+	setting := helpers.FetchCredential(name)
+	if setting == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(setting)
+	if err != nil {
+		log.Printf("Invalid %s, using %s: %s", name, fallback, err)
+		return fallback
+	}
+	if duration <= 0 {
+		log.Printf("Invalid %s, using %s: %s is not positive", name, fallback, setting)
+		return fallback
+	}
+	return duration
+}
 
-	return &Bot{userEmailMap, slack, tock, messageRepo, violatorUserMap, masterList}
+// splitList splits a comma separated setting and drops empty entries
+func splitList(setting string) []string {
+	var list []string
+	for _, item := range strings.Split(setting, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
 }
 
 // Check if user is in masterList
@@ -77,89 +258,157 @@ func (bot *Bot) StoreSlackUsers() {
 		if strings.HasSuffix(user.Profile.Email, ".gov") {
 			bot.UserEmailMap.Update(user.Profile.Email, user.ID)
 			bot.updateMasterList(user.Profile.Email, user.ID)
-		}
-	}
-}
-
-// updateviolatorUserMap generates a new map containing the slack id and user email
-// of late tock users
-func (bot *Bot) updateviolatorUserMap() {
-	violatorUserMap := make(map[string]string)
-	bot.Tock.UserApplier(
-		func(user tockPackage.User) {
-			userID := bot.UserEmailMap.Get(user.Email)
-			if user.Email != "" && userID != "" {
-				violatorUserMap[userID] = user.Email
+			if user.Locale != "" {
+				bot.userLocales.Update(user.ID, user.Locale)
 			}
-		},
-	)
-	bot.violatorUserMap.Replace(violatorUserMap)
-}
-
-// startviolatorUserMapUpdater begins a ticker that only keeps the
-// violator list full for 30 minutes
-func (bot *Bot) startviolatorUserMapUpdater() {
-	// Collect user data
-	bot.updateviolatorUserMap()
-	// Create a ticker to renew the cache of tock users
-	ticker := time.NewTicker(30 * time.Minute)
-	// Start the go channel
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				bot.violatorUserMap.Replace(make(map[string]string))
-				ticker.Stop()
-				return
+			if user.TZ != "" {
+				bot.userTimezones.Update(user.ID, user.TZ)
 			}
 		}
-	}()
+	}
 }
 
 // SlapLateUsers collects users from tock and looks for thier slack ids in a database
 func (bot *Bot) SlapLateUsers() {
 	log.Println("Slapping Tock Users")
-	bot.Tock.UserApplier(
-		func(user tockPackage.User) {
-			userID := bot.UserEmailMap.Get(user.Email)
-			if userID != "" {
-				bot.Slack.MessageUser(
-					userID,
-					bot.MessageRepo.Reminder.GenerateMessage(bot.Tock.UserTockURL),
-				)
-			}
-		},
-	)
+	period, _ := bot.Tock.FetchCurrentAndPreviousPeriods()
+	if period == nil {
+		log.Println("Unable to find the current reporting period")
+		return
+	}
+	slapUser := bot.slapUser(period)
+	count := 0
+	bot.Tock.PeriodUserApplier(period.StartDate, func(user tockPackage.User) {
+		if slapUser(user) {
+			count++
+		}
+	})
+	bot.recordReminderRun("slap users", period.StartDate, count)
+}
+
+// SlapUnitUsers reminds the late users of a single tock unit
+func (bot *Bot) SlapUnitUsers(unit string) {
+	log.Printf("Slapping Tock Users in %s", unit)
+	period, _ := bot.Tock.FetchCurrentAndPreviousPeriods()
+	if period == nil {
+		log.Println("Unable to find the current reporting period")
+		return
+	}
+	slapUser := bot.slapUser(period)
+	count := 0
+	err := bot.Tock.ProfiledUserApplier(period.StartDate, func(user tockPackage.User) {
+		if user.InUnit(unit) && slapUser(user) {
+			count++
+		}
+	})
+	if err != nil {
+		log.Printf("Unable to slap every late user in %s: %s", unit, err)
+	}
+	bot.recordReminderRun(fmt.Sprintf("slap users in %s", unit), period.StartDate, count)
+}
+
+// slapUser returns a function that sends a reminder message to a late user
+// if they are in slack and reports if it did
+func (bot *Bot) slapUser(period *tockPackage.ReportingPeriod) func(user tockPackage.User) bool {
+	return func(user tockPackage.User) bool {
+		userID := bot.UserEmailMap.Get(user.Email)
+		if userID == "" || bot.isPaused(userID) {
+			return false
+		}
+		tone := bot.toneFor(userID, "")
+		bot.Slack.MessageUser(
+			userID,
+			tone.Reminder.GenerateMessage(bot.periodMessageData(userID, period), tone.Tags...),
+		)
+		bot.remindedUsers.Update(userID, period.StartDate)
+		return true
+	}
+}
+
+// messagesFor returns the messages in a slack user's language. A language
+// chosen with the `language` command wins over the slack locale.
+func (bot *Bot) messagesFor(userID string) *messagesPackage.MessageRepository {
+	locale := bot.localePreferences.Get(userID)
+	if locale == "" {
+		locale = bot.userLocales.Get(userID)
+	}
+	return bot.MessageRepo.Localized(locale)
+}
+
+// toneFor returns the messages for the tone a user chose, the tone of the
+// channel or the default tone, in that order. Translations without the tone
+// use the tone of the default messages. channel can be empty for direct
+// messages.
+func (bot *Bot) toneFor(userID string, channel string) *messagesPackage.ToneMessages {
+	tone := bot.userTones.Get(userID)
+	if tone == "" && channel != "" {
+		tone = bot.channelTones.Get(channel)
+	}
+	if tone == "" {
+		tone = bot.defaultTone
+	}
+	return bot.messagesFor(userID).TranslatedTone(tone, bot.MessageRepo.Current())
+}
+
+// messageData returns the data used to fill in messages for a slack user
+func (bot *Bot) messageData(userID string) messagesPackage.MessageData {
+	data := messagesPackage.NewMessageData(userID)
+	data.TockURL = bot.Tock.UserTockURL
+	return data
+}
+
+// periodMessageData returns the data used to fill in messages for a slack
+// user about a reporting period
+func (bot *Bot) periodMessageData(userID string, period *tockPackage.ReportingPeriod) messagesPackage.MessageData {
+	data := bot.messageData(userID)
+	mrep := bot.messagesFor(userID)
+	data.PeriodStart = mrep.FormatDate(period.StartDate)
+	data.PeriodEnd = mrep.FormatDate(period.EndDate)
+	data.HoursRequired = period.ExactWorkingHours
+	if data.HoursRequired == 0 {
+		data.HoursRequired = period.MinWorkingHours
+	}
+	return data
 }
 
 // RemindUsers collects users from tock and looks for thier slack ids in a database
 func (bot *Bot) RemindUsers(message string) {
 	log.Printf("Reminding Tock Users with `%s`", message)
-	bot.Tock.UserApplier(
+	timePeriod := bot.Tock.CurrentReportingPeriod()
+	count := 0
+	bot.Tock.PeriodUserApplier(
+		timePeriod,
 		func(user tockPackage.User) {
 			userID := bot.UserEmailMap.Get(user.Email)
-			if userID != "" {
+			if userID != "" && !bot.isPaused(userID) {
 				bot.Slack.MessageUser(
 					userID, message,
 				)
+				bot.remindedUsers.Update(userID, timePeriod)
+				count++
 			}
 		},
 	)
+	bot.recordReminderRun("remind users", timePeriod, count)
 }
 
-// ListenToSlackUsers starts a loop that listens to tock users
-func (bot *Bot) ListenToSlackUsers() {
+// listen handles slack events until the context is done or slack rejects
+// the bot's credentials
+func (bot *Bot) listen(ctx context.Context) error {
 	log.Println("Listening to slack")
-	go bot.Slack.ManageConnection()
 	// Creating a for loop to catch channel messages from slack
 	for {
 		select {
-		case rtmEvent := <-bot.Slack.IncomingEvents:
+		case <-ctx.Done():
+			return nil
+		case rtmEvent := <-bot.rtm.Events():
 			switch event := rtmEvent.Data.(type) {
 			case *slack.HelloEvent:
 				// Ignore hello
 			case *slack.ConnectedEvent:
-				// Ignore PresenceChangeEvent
+				bot.connected.Store(true)
+			case *slack.DisconnectedEvent:
+				bot.connected.Store(false)
 			case *slack.MessageEvent:
 				bot.processMessage(event)
 			case *slack.PresenceChangeEvent:
@@ -170,9 +419,7 @@ func (bot *Bot) ListenToSlackUsers() {
 				// Show errors
 				fmt.Printf("Error: %s\n", event.Error())
 			case *slack.InvalidAuthEvent:
-				fmt.Printf("Invalid credentials")
-				break
-
+				return ErrInvalidAuth
 			default:
 				// Do nothing
 			}
@@ -180,36 +427,60 @@ func (bot *Bot) ListenToSlackUsers() {
 	}
 }
 
-// isLateUser returns if the user is late.
-func (bot *Bot) isLateUser(slackUserID string) bool {
-	found := false
-	bot.Tock.UserApplier(
+// ListenToSlackUsers runs the bot until slack rejects its credentials
+//
+// Deprecated: use Run.
+func (bot *Bot) ListenToSlackUsers() {
+	if err := bot.Run(context.Background()); err != nil {
+		log.Print(err)
+	}
+}
+
+// lateSlackUsers returns the slack ids of the users who are late for the
+// current reporting period, or an error if tock can't say who is late
+func (bot *Bot) lateSlackUsers() (map[string]bool, error) {
+	late := make(map[string]bool)
+	err := bot.Tock.UserApplier(
 		func(user tockPackage.User) {
-			userID := bot.UserEmailMap.Get(user.Email)
-			if slackUserID == userID {
-				found = true
+			if userID := bot.UserEmailMap.Get(user.Email); userID != "" {
+				late[userID] = true
 			}
 		},
 	)
-	return found
+	return late, err
 }
 
-// fetchLateUsers returns a list of late users
-func (bot *Bot) fetchLateUsers() (string, int) {
+// isLateUser returns if the user is late.
+func (bot *Bot) isLateUser(slackUserID string) (bool, error) {
+	late, err := bot.lateSlackUsers()
+	return late[slackUserID], err
+}
+
+// fetchLateUsers returns a list of late users. If unit is not empty only
+// users in that tock unit are listed. An error is returned if tock can't
+// list every late user.
+func (bot *Bot) fetchLateUsers(unit string) (string, int, error) {
 	var lateList string
 	var counter int
 
-	bot.Tock.UserApplier(
-		func(user tockPackage.User) {
-			slackUserID := bot.UserEmailMap.Get(user.Email)
-			if slackUserID != "" {
-				lateList += fmt.Sprintf("<@%s>, ", slackUserID)
-				counter++
-			}
-		},
-	)
+	collectUser := func(user tockPackage.User) {
+		slackUserID := bot.UserEmailMap.Get(user.Email)
+		if slackUserID != "" {
+			lateList += fmt.Sprintf("<@%s>, ", slackUserID)
+			counter++
+		}
+	}
+	var err error
+	if unit == "" {
+		err = bot.Tock.UserApplier(collectUser)
+	} else {
+		err = bot.Tock.UnitUserApplier(unit, collectUser)
+	}
+	if err != nil {
+		return "", 0, err
+	}
 	if lateList == "" {
 		lateList = "No people"
 	}
-	return lateList, counter
+	return lateList, counter, nil
 }
@@ -0,0 +1,174 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/18F/angrytock/helpers"
+	"github.com/18F/angrytock/messages"
+	"github.com/18F/angrytock/safeDict"
+	"github.com/18F/angrytock/tock"
+	"github.com/nlopes/slack"
+)
+
+// testTockURL is the tock url of the bots made by newTestBot
+const testTockURL = "https://tock.example.gov"
+
+// testPeriods are the reporting periods tock lists in the tests, most recent
+// first. They have all ended.
+const testPeriods = `[
+	{"start_date":"2024-01-15","end_date":"2024-01-21","exact_working_hours":40},
+	{"start_date":"2024-01-08","end_date":"2024-01-14","exact_working_hours":40},
+	{"start_date":"2024-01-01","end_date":"2024-01-07","min_working_hours":32,"max_working_hours":40}
+]`
+
+// testAuditPath returns the path of the late users of a period in the tests
+func testAuditPath(timePeriod string) string {
+	return "/api/reporting_period_audit/" + timePeriod + ".json?page=1"
+}
+
+// newTestBot returns a bot with empty dictionaries and the embedded messages
+// that gets tock responses by path, e.g. `/api/user_data.json`. Paths
+// without a response fail like an unreachable tock api.
+func newTestBot(t *testing.T, responses map[string]string) *Bot {
+	messageRepo, err := messagesPackage.NewMessageStore(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := func(URL string) []byte {
+		body, ok := responses[strings.TrimPrefix(URL, testTockURL)]
+		if !ok {
+			return nil
+		}
+		return []byte(body)
+	}
+	bot := &Bot{
+		UserEmailMap: safeDict.InitSafeDict[string, string](),
+		Tock: &tockPackage.Tock{
+			TockURL:       testTockURL,
+			UserTockURL:   testTockURL + "/employees",
+			AuditEndpoint: testTockURL + "/api/reporting_period_audit",
+			DataFetcher:   helpers.NewDataFetcher(fetcher),
+		},
+		MessageRepo:        messageRepo,
+		violatorUserMap:    safeDict.InitSafeDict[string, botherEntry](),
+		supervisorNotified: safeDict.InitSafeDict[string, string](),
+		remindedUsers:      safeDict.InitSafeDict[string, string](),
+		userLocales:        safeDict.InitSafeDict[string, string](),
+		localePreferences:  safeDict.InitSafeDict[string, string](),
+		defaultTone:        "snarky",
+		channelTones:       safeDict.InitSafeDict[string, string](),
+		userTones:          safeDict.InitSafeDict[string, string](),
+		defaultNudgeStyle:  "message",
+		channelNudgeStyles: safeDict.InitSafeDict[string, string](),
+		pausedUsers:        safeDict.InitSafeDict[string, string](),
+		userTimezones:      safeDict.InitSafeDict[string, string](),
+		personalReminders:  safeDict.InitSafeDict[string, []personalReminder](),
+		allowedChannels:    map[string]bool{},
+		deniedChannels:     map[string]bool{},
+	}
+	t.Cleanup(bot.closeState)
+	return bot
+}
+
+// Check the late list for everyone and for a unit and that tock errors
+// aren't reported as nobody being late
+func TestFetchLateUsers(t *testing.T) {
+	responses := map[string]string{
+		"/api/reporting_period_audit.json": testPeriods,
+		testAuditPath("2024-01-15"): `[
+			{"username":"ada","email":"ada@example.gov"},
+			{"username":"grace","email":"grace@example.gov"}
+		]`,
+		"/api/user_data.json": `[{"user":"ada","unit":"Engineering"},{"user":"grace","unit":"Design"}]`,
+	}
+	bot := newTestBot(t, responses)
+	bot.UserEmailMap.Update("ada@example.gov", "U1")
+	bot.UserEmailMap.Update("grace@example.gov", "U2")
+	tests := []struct {
+		Unit  string
+		List  string
+		Total int
+	}{
+		{"", "<@U1>, <@U2>, ", 2},
+		{"engineering", "<@U1>, ", 1},
+		{"Marketing", "No people", 0},
+	}
+	for _, test := range tests {
+		list, total, err := bot.fetchLateUsers(test.Unit)
+		if err != nil || list != test.List || total != test.Total {
+			t.Errorf("%q: %q %d %v", test.Unit, list, total, err)
+		}
+	}
+
+	delete(responses, "/api/user_data.json")
+	if _, _, err := bot.fetchLateUsers("Engineering"); err == nil {
+		t.Error("a unit's late list needs the user data")
+	}
+	delete(responses, testAuditPath("2024-01-15"))
+	if _, _, err := bot.fetchLateUsers(""); err == nil {
+		t.Error("the late list needs every page of late users")
+	}
+}
+
+// Check that missing, invalid and non-positive durations use the fallback
+func TestDurationSetting(t *testing.T) {
+	tests := []struct {
+		Setting string
+		Output  time.Duration
+	}{
+		{"", time.Minute},
+		{"90s", 90 * time.Second},
+		{"2h", 2 * time.Hour},
+		{"soon", time.Minute},
+		{"0s", time.Minute},
+		{"0", time.Minute},
+		{"-5m", time.Minute},
+	}
+	for _, test := range tests {
+		t.Setenv("ANGRYTOCK_TEST_INTERVAL", test.Setting)
+		if duration := durationSetting("ANGRYTOCK_TEST_INTERVAL", time.Minute); duration != test.Output {
+			t.Errorf("%q: %s", test.Setting, duration)
+		}
+	}
+}
+
+// fakeSlack records the messages and reactions the bot sends, e.g.
+// `dm U1: text`
+type fakeSlack struct {
+	sent []string
+}
+
+func (api *fakeSlack) GetSelfID() string {
+	return "UBOT"
+}
+
+func (api *fakeSlack) FetchSlackUsers() []slack.User {
+	return nil
+}
+
+func (api *fakeSlack) SendToChannel(channelID string, message string) {
+	api.sent = append(api.sent, fmt.Sprintf("channel %s: %s", channelID, message))
+}
+
+func (api *fakeSlack) MessageUser(user string, message string) {
+	api.sent = append(api.sent, fmt.Sprintf("dm %s: %s", user, message))
+}
+
+func (api *fakeSlack) MessageChannel(channelID string, message string) {
+	api.sent = append(api.sent, fmt.Sprintf("post %s: %s", channelID, message))
+}
+
+func (api *fakeSlack) MessageUserEphemeral(channelID string, user string, message string) {
+	api.sent = append(api.sent, fmt.Sprintf("ephemeral %s %s: %s", channelID, user, message))
+}
+
+func (api *fakeSlack) ReactToMessage(channelID string, timestamp string, emoji string) {
+	api.sent = append(api.sent, fmt.Sprintf("react %s %s: %s", channelID, timestamp, emoji))
+}
+
+func (api *fakeSlack) ReplyInThread(channelID string, threadTimestamp string, message string) {
+	api.sent = append(api.sent, fmt.Sprintf("thread %s %s: %s", channelID, threadTimestamp, message))
+}
@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/18F/angrytock/tock"
+)
+
+const (
+	// defaultBotherWindow is how long late users are bothered unless the
+	// command gives a duration
+	defaultBotherWindow = 30 * time.Minute
+	// botherCooldown is the least time between two messages to the same user
+	botherCooldown = 10 * time.Minute
+)
+
+// botherEntry is a late user on the bother watchlist
+type botherEntry struct {
+	Email string `json:"email"`
+	// Channel limits bothering to one channel, all channels if empty
+	Channel string `json:"channel"`
+	// Remaining is how many more times the user will be bothered
+	Remaining    int       `json:"remaining"`
+	LastBothered time.Time `json:"last_bothered"`
+}
+
+// botherSettings are the options of a `bother users` command
+type botherSettings struct {
+	Window  time.Duration
+	Channel string
+	Times   int
+}
+
+// parseBotherSettings reads the options from a command such as
+// `bother users for 2h in #general max 3 times`
+func parseBotherSettings(text string) (botherSettings, error) {
+	settings := botherSettings{Window: defaultBotherWindow, Times: 1}
+	durationFinder := regexp.MustCompile(`for\s+(\S+)`)
+	timesFinder := regexp.MustCompile(`max\s+(\d+)\s+times?`)
+	channelFinder := regexp.MustCompile(`<#([A-Z0-9]+)(?:\|[^>]*)?>`)
+	if found := durationFinder.FindStringSubmatch(text); found != nil {
+		window, err := time.ParseDuration(strings.TrimRight(found[1], "!.,"))
+		if err != nil || window <= 0 {
+			return settings, fmt.Errorf("I don't understand the duration `%s`, try `2h` or `45m`", found[1])
+		}
+		settings.Window = window
+	}
+	if found := timesFinder.FindStringSubmatch(text); found != nil {
+		times, err := strconv.Atoi(found[1])
+		if err != nil || times < 1 {
+			return settings, fmt.Errorf("I can only bother users a positive number of times")
+		}
+		settings.Times = times
+	}
+	if found := channelFinder.FindStringSubmatch(text); found != nil {
+		settings.Channel = found[1]
+	}
+	return settings, nil
+}
+
+// describe summarizes the settings for the reply to the command
+func (settings botherSettings) describe() string {
+	where := "everywhere"
+	if settings.Channel != "" {
+		where = fmt.Sprintf("in <#%s>", settings.Channel)
+	}
+	return fmt.Sprintf("for %s %s, up to %s each", settings.Window, where, timesText(settings.Times))
+}
+
+// timesText writes a count of times, e.g. `1 time` or `3 times`
+func timesText(count int) string {
+	if count == 1 {
+		return "1 time"
+	}
+	return fmt.Sprintf("%d times", count)
+}
+
+// updateviolatorUserMap adds the slack ids and emails of late tock users to
+// the watchlist. Users already on it get the new settings and window.
+func (bot *Bot) updateviolatorUserMap(settings botherSettings) {
+	bot.Tock.UserApplier(
+		func(user tockPackage.User) {
+			userID := bot.UserEmailMap.Get(user.Email)
+			if user.Email != "" && userID != "" {
+				bot.violatorUserMap.SetWithTTL(userID, botherEntry{
+					Email:     user.Email,
+					Channel:   settings.Channel,
+					Remaining: settings.Times,
+				}, settings.Window)
+			}
+		},
+	)
+}
+
+// startviolatorUserMapUpdater fills the watchlist. Entries expire on their
+// own, so running it again only extends the bother window.
+func (bot *Bot) startviolatorUserMapUpdater(settings botherSettings) {
+	bot.updateviolatorUserMap(settings)
+}
+
+// shouldBother checks if a user on the watchlist can be bothered in a
+// channel right now
+func shouldBother(entry botherEntry, channel string, now time.Time) bool {
+	if entry.Channel != "" && entry.Channel != channel {
+		return false
+	}
+	return entry.LastBothered.IsZero() || now.Sub(entry.LastBothered) >= botherCooldown
+}
+
+// recordBother counts a message sent to a user on the watchlist and takes
+// them off it when they have been bothered enough
+func (bot *Bot) recordBother(user string, entry botherEntry, now time.Time) {
+	entry.Remaining--
+	expiration, ok := bot.violatorUserMap.ExpiresAt(user)
+	if entry.Remaining <= 0 || !ok {
+		bot.violatorUserMap.Delete(user)
+		return
+	}
+	entry.LastBothered = now
+	bot.violatorUserMap.SetWithTTL(user, entry, expiration.Sub(now))
+}
+
+// stopBothering takes the mentioned users off the watchlist, or everyone if
+// nobody is mentioned
+func (bot *Bot) stopBothering(text string) string {
+	botID := bot.Slack.GetSelfID()
+	mentionFinder := regexp.MustCompile(`<@([A-Z0-9]+)>`)
+	var users []string
+	for _, found := range mentionFinder.FindAllStringSubmatch(text, -1) {
+		if found[1] != botID {
+			users = append(users, found[1])
+		}
+	}
+	if len(users) == 0 {
+		for _, user := range bot.violatorUserMap.Keys() {
+			bot.violatorUserMap.Delete(user)
+		}
+		return "Stopped bothering users!"
+	}
+	for _, user := range users {
+		bot.violatorUserMap.Delete(user)
+	}
+	return fmt.Sprintf("Stopped bothering <@%s>!", strings.Join(users, ">, <@"))
+}
+
+// botherStatus lists the users on the watchlist with their remaining time
+func (bot *Bot) botherStatus() string {
+	watchlist := bot.violatorUserMap.Snapshot()
+	if len(watchlist) == 0 {
+		return "Nobody is being bothered."
+	}
+	users := make([]string, 0, len(watchlist))
+	for user := range watchlist {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	lines := []string{fmt.Sprintf("Bothering %d users:", len(users))}
+	now := time.Now()
+	for _, user := range users {
+		entry := watchlist[user]
+		expiration, ok := bot.violatorUserMap.ExpiresAt(user)
+		if !ok {
+			continue
+		}
+		where := "everywhere"
+		if entry.Channel != "" {
+			where = fmt.Sprintf("in <#%s>", entry.Channel)
+		}
+		lines = append(lines, fmt.Sprintf(
+			"• %s: %s left %s, %s more",
+			entry.Email, expiration.Sub(now).Round(time.Minute), where, timesText(entry.Remaining),
+		))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Check the duration, channel and count options of `bother users`
+func TestParseBotherSettings(t *testing.T) {
+	tests := []struct {
+		Text     string
+		Settings botherSettings
+		Error    string
+	}{
+		{"<@UBOT> bother users!", botherSettings{Window: defaultBotherWindow, Times: 1}, ""},
+		{"<@UBOT> bother users for 2h", botherSettings{Window: 2 * time.Hour, Times: 1}, ""},
+		{
+			"<@UBOT> bother users for 45m in <#C1234|general> max 3 times!",
+			botherSettings{Window: 45 * time.Minute, Channel: "C1234", Times: 3},
+			"",
+		},
+		{"<@UBOT> bother users in <#C1234> max 1 time", botherSettings{Window: defaultBotherWindow, Channel: "C1234", Times: 1}, ""},
+		{"<@UBOT> bother users for a while", botherSettings{}, "I don't understand the duration `a`"},
+		{"<@UBOT> bother users for -1h", botherSettings{}, "I don't understand the duration"},
+		{"<@UBOT> bother users max 0 times", botherSettings{}, "positive number of times"},
+	}
+	for _, test := range tests {
+		settings, err := parseBotherSettings(test.Text)
+		switch {
+		case test.Error != "" && (err == nil || !strings.Contains(err.Error(), test.Error)):
+			t.Errorf("%s: expected %s, got %v", test.Text, test.Error, err)
+		case test.Error == "" && (err != nil || settings != test.Settings):
+			t.Errorf("%s: %+v %v", test.Text, settings, err)
+		}
+	}
+}
+
+// Check that users are only bothered in their channel and not more often
+// than the cooldown
+func TestShouldBother(t *testing.T) {
+	now := time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		Entry   botherEntry
+		Channel string
+		Output  bool
+	}{
+		{botherEntry{Remaining: 1}, "C1234", true},
+		{botherEntry{Channel: "C1234", Remaining: 1}, "C1234", true},
+		{botherEntry{Channel: "C1234", Remaining: 1}, "C5678", false},
+		{botherEntry{Remaining: 2, LastBothered: now.Add(-time.Minute)}, "C1234", false},
+		{botherEntry{Remaining: 2, LastBothered: now.Add(-botherCooldown)}, "C1234", true},
+	}
+	for _, test := range tests {
+		if bother := shouldBother(test.Entry, test.Channel, now); bother != test.Output {
+			t.Errorf("%+v in %s: %t", test.Entry, test.Channel, bother)
+		}
+	}
+}
+
+// Check that users leave the watchlist once they have been bothered enough
+func TestRecordBother(t *testing.T) {
+	bot := newTestBot(t, nil)
+	now := time.Now()
+	bot.violatorUserMap.SetWithTTL("U1", botherEntry{Email: "ada@example.gov", Remaining: 2}, time.Hour)
+	bot.recordBother("U1", bot.violatorUserMap.Get("U1"), now)
+	entry, ok := bot.violatorUserMap.GetOK("U1")
+	if !ok || entry.Remaining != 1 || !entry.LastBothered.Equal(now) {
+		t.Error(entry, ok)
+	}
+	if expiration, ok := bot.violatorUserMap.ExpiresAt("U1"); !ok || expiration.Sub(now) > time.Hour+time.Second {
+		t.Error("the bother window should not be extended", expiration)
+	}
+	bot.recordBother("U1", entry, now)
+	if _, ok := bot.violatorUserMap.GetOK("U1"); ok {
+		t.Error("U1 should be off the watchlist")
+	}
+}
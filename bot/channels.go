@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+// handledSubtypes are the message subtypes the bot reads. Edits, joins, bot
+// messages and other events are ignored.
+var handledSubtypes = map[string]bool{
+	"":                 true,
+	"thread_broadcast": true,
+	"file_share":       true,
+}
+
+// isDirectMessage checks if a channel id is a direct message with the bot
+func isDirectMessage(channel string) bool {
+	return strings.HasPrefix(channel, "D")
+}
+
+// channelSet turns a list of channel ids into a set
+func channelSet(channels []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, channel := range channels {
+		set[channel] = true
+	}
+	return set
+}
+
+// channelAllowed checks the allow and deny lists for a channel. Denied
+// channels always lose and direct messages are always allowed.
+func (bot *Bot) channelAllowed(channel string) bool {
+	switch {
+	case bot.deniedChannels[channel]:
+		return false
+	case isDirectMessage(channel):
+		return true
+	case len(bot.allowedChannels) > 0:
+		return bot.allowedChannels[channel]
+	}
+	return true
+}
+
+// shouldHandle checks if a message was written by a person in a channel
+// where the bot may speak
+func (bot *Bot) shouldHandle(message *slack.MessageEvent, botID string) bool {
+	switch {
+	case !handledSubtypes[message.SubType]:
+		return false
+	case message.BotID != "" || message.User == "" || message.User == botID:
+		return false
+	}
+	return bot.channelAllowed(message.Channel)
+}
+
+// reply answers a message in its thread, or in the channel if the message
+// is not in a thread
+func (bot *Bot) reply(message *slack.MessageEvent, text string) {
+	if text == "" {
+		return
+	}
+	if message.ThreadTimestamp != "" {
+		bot.Slack.ReplyInThread(message.Channel, message.ThreadTimestamp, text)
+		return
+	}
+	bot.Slack.SendToChannel(message.Channel, text)
+}
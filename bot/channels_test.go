@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/nlopes/slack"
+)
+
+// Check the allow and deny lists
+func TestChannelAllowed(t *testing.T) {
+	tests := []struct {
+		Allowed []string
+		Denied  []string
+		Channel string
+		Output  bool
+	}{
+		{nil, nil, "C1234", true},
+		{nil, nil, "D1234", true},
+		{[]string{"C1234"}, nil, "C1234", true},
+		{[]string{"C1234"}, nil, "C5678", false},
+		{[]string{"C1234"}, nil, "D1234", true},
+		{nil, []string{"C1234"}, "C1234", false},
+		{[]string{"C1234"}, []string{"C1234"}, "C1234", false},
+		{nil, []string{"D1234"}, "D1234", false},
+	}
+	for _, test := range tests {
+		bot := &Bot{allowedChannels: channelSet(test.Allowed), deniedChannels: channelSet(test.Denied)}
+		if allowed := bot.channelAllowed(test.Channel); allowed != test.Output {
+			t.Errorf("%s with %v and %v: %t", test.Channel, test.Allowed, test.Denied, allowed)
+		}
+	}
+}
+
+// Check that only messages people write in allowed channels are handled
+func TestShouldHandle(t *testing.T) {
+	bot := &Bot{allowedChannels: channelSet(nil), deniedChannels: channelSet([]string{"C9999"})}
+	message := func(user string, channel string, subType string, botID string) *slack.MessageEvent {
+		event := &slack.MessageEvent{}
+		event.User = user
+		event.Channel = channel
+		event.SubType = subType
+		event.BotID = botID
+		return event
+	}
+	tests := []struct {
+		Message *slack.MessageEvent
+		Output  bool
+	}{
+		{message("U1", "C1234", "", ""), true},
+		{message("U1", "C1234", "thread_broadcast", ""), true},
+		{message("U1", "D1234", "file_share", ""), true},
+		{message("U1", "C1234", "message_changed", ""), false},
+		{message("U1", "C1234", "channel_join", ""), false},
+		{message("U1", "C1234", "", "B1234"), false},
+		{message("", "C1234", "", ""), false},
+		{message("UBOT", "C1234", "", ""), false},
+		{message("U1", "C9999", "", ""), false},
+	}
+	for _, test := range tests {
+		if handle := bot.shouldHandle(test.Message, "UBOT"); handle != test.Output {
+			t.Errorf("%+v: %t", test.Message.Msg, handle)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/nlopes/slack"
+)
+
+// defaultPause is how long `pause` stops reminders without a duration
+const defaultPause = 24 * time.Hour
+
+// niceCommands are the words that start the commands in niceMessage
+var niceCommands = []string{"hello", "tone", "language", "streak", "leaderboard", "status"}
+
+// conversationHelp lists the commands users can send the bot privately
+const conversationHelp = "Here's what you can ask me in a direct message:\n" +
+	"`status` : check if your timesheet is filled out\n" +
+	"`streak` : how many periods in a row you were on time\n" +
+	"`leaderboard` : the units that are best at Tock\n" +
+	"`remind me every friday at 4pm` or `remind me tomorrow at 9` : get a reminder if your timesheet is still missing then\n" +
+	"`reminders` or `cancel reminders` : see or remove your reminders\n" +
+	"`pause` or `pause for 3d` : stop reminders and nudges for a while\n" +
+	"`resume` : start reminders and nudges again\n" +
+	"`language es` or `tone professional` : change how I talk to you"
+
+// commandWords splits a message into its words
+func commandWords(text string) []string {
+	return strings.FieldsFunc(text, func(char rune) bool {
+		return !unicode.IsLetter(char)
+	})
+}
+
+// isCommand checks if a word is one of the commands
+func isCommand(word string, commands []string) bool {
+	for _, command := range commands {
+		if word == command {
+			return true
+		}
+	}
+	return false
+}
+
+// containsCommand checks if a message has one of the commands as a word
+func containsCommand(text string, commands []string) bool {
+	for _, word := range commandWords(text) {
+		if isCommand(word, commands) {
+			return true
+		}
+	}
+	return false
+}
+
+// startsWithCommand checks if the first word of a message is one of the
+// commands
+func startsWithCommand(text string, commands []string) bool {
+	words := commandWords(text)
+	return len(words) > 0 && isCommand(words[0], commands)
+}
+
+// commandText returns the lower case text of a message without the bot's
+// mention in front, e.g. `streak` for `<@U1234>: Streak`
+func commandText(text string, botID string) string {
+	text = strings.TrimSpace(strings.TrimPrefix(text, fmt.Sprintf("<@%s>", botID)))
+	return strings.ToLower(strings.TrimLeft(text, ": "))
+}
+
+// directMessage handles a message in a direct message with the bot. Users
+// don't need to mention the bot and unknown messages get the help.
+func (bot *Bot) directMessage(message *slack.MessageEvent, user string, botID string) {
+	text := commandText(message.Text, botID)
+	switch {
+	case text == "help":
+		bot.reply(message, conversationHelp)
+	case strings.HasPrefix(text, "pause"):
+		bot.reply(message, bot.pauseUser(text, user))
+	case strings.HasPrefix(text, "resume"):
+		bot.pausedUsers.Delete(user)
+		bot.reply(message, "Welcome back! Reminders and nudges are on again.")
+	case strings.HasPrefix(text, "remind me"):
+		bot.reply(message, bot.scheduleReminder(text, user, time.Now()))
+	case strings.HasPrefix(text, "cancel reminders"):
+		bot.reply(message, bot.cancelReminders(user))
+	case strings.HasPrefix(text, "reminders"):
+		bot.reply(message, bot.listReminders(user))
+	// Admins get the commands for everyone too, while admin commands such
+	// as `bother status` still go to masterMessages
+	case startsWithCommand(text, niceCommands):
+		bot.niceMessage(message, user)
+	case bot.isMasterUser(user):
+		bot.masterMessages(message)
+	case containsCommand(text, niceCommands):
+		bot.niceMessage(message, user)
+	default:
+		bot.reply(message, conversationHelp)
+	}
+}
+
+// parseLongDuration parses a duration that can also be given in days, e.g.
+// `3d`
+func parseLongDuration(text string) (time.Duration, error) {
+	if days := strings.TrimSuffix(text, "d"); days != text {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(text)
+}
+
+// pauseUser stops reminders and nudges for a user for a while, e.g.
+// `pause for 3d`
+func (bot *Bot) pauseUser(text string, user string) string {
+	pause := defaultPause
+	durationFinder := regexp.MustCompile(`for\s+(\S+)`)
+	if found := durationFinder.FindStringSubmatch(text); found != nil {
+		duration, err := parseLongDuration(strings.TrimRight(found[1], "!.,"))
+		if err != nil || duration <= 0 {
+			return fmt.Sprintf("I don't understand `%s`, try `pause for 2h` or `pause for 3d`.", found[1])
+		}
+		pause = duration
+	}
+	bot.pausedUsers.SetWithTTL(user, "paused", pause)
+	until := time.Now().Add(pause).In(bot.userLocation(user))
+	return fmt.Sprintf(
+		"Okay, no reminders or nudges until %s. Say `resume` to start them again.",
+		until.Format("Mon Jan 2 3:04PM"),
+	)
+}
+
+// isPaused checks if a user paused reminders and nudges
+func (bot *Bot) isPaused(userID string) bool {
+	_, paused := bot.pausedUsers.GetOK(userID)
+	return paused
+}
+
+// userLocation returns a user's slack time zone or the server's time zone
+func (bot *Bot) userLocation(userID string) *time.Location {
+	timezone := bot.userTimezones.Get(userID)
+	if timezone == "" {
+		return time.Local
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
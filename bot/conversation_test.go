@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+// Check that commands are only found as words
+func TestContainsCommand(t *testing.T) {
+	tests := []struct {
+		Text   string
+		Output bool
+	}{
+		{"status", true},
+		{"what's my status?", true},
+		{"hello!", true},
+		{"the milestone", false},
+		{"statuses", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if found := containsCommand(test.Text, niceCommands); found != test.Output {
+			t.Errorf("%q: %t", test.Text, found)
+		}
+	}
+}
+
+// Check that only messages starting with a command are found
+func TestStartsWithCommand(t *testing.T) {
+	tests := []struct {
+		Text   string
+		Output bool
+	}{
+		{"streak", true},
+		{"leaderboard please", true},
+		{"tone professional", true},
+		{"bother status", false},
+		{"channel tone professional", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if found := startsWithCommand(test.Text, niceCommands); found != test.Output {
+			t.Errorf("%q: %t", test.Text, found)
+		}
+	}
+}
+
+// Check that the bot's mention is removed before looking for commands, so
+// admins mentioning the bot get the commands for everyone
+func TestCommandText(t *testing.T) {
+	tests := []struct {
+		Text    string
+		Output  string
+		Command bool
+	}{
+		{"<@UBOT> streak", "streak", true},
+		{"<@UBOT>: Language es", "language es", true},
+		{"<@UBOT>:leaderboard", "leaderboard", true},
+		{"status", "status", true},
+		{"<@UBOT> bother status", "bother status", false},
+		{"<@UBOT> who is late?", "who is late?", false},
+		{"<@UOTHER> streak", "<@uother> streak", false},
+	}
+	for _, test := range tests {
+		text := commandText(test.Text, "UBOT")
+		if text != test.Output || startsWithCommand(text, niceCommands) != test.Command {
+			t.Errorf("%q: %q", test.Text, text)
+		}
+	}
+}
+
+// Check durations given in days and in go's format
+func TestParseLongDuration(t *testing.T) {
+	tests := []struct {
+		Text     string
+		Duration time.Duration
+		Error    bool
+	}{
+		{"3d", 72 * time.Hour, false},
+		{"1d", 24 * time.Hour, false},
+		{"2h", 2 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"d", 0, true},
+		{"1.5d", 0, true},
+		{"soon", 0, true},
+	}
+	for _, test := range tests {
+		duration, err := parseLongDuration(test.Text)
+		if duration != test.Duration || (err != nil) != test.Error {
+			t.Errorf("%s: %s %v", test.Text, duration, err)
+		}
+	}
+}
+
+// Check that times of day are read in 12 and 24 hour formats
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		Text   string
+		Hour   int
+		Minute int
+		OK     bool
+	}{
+		{"remind me at 4pm", 16, 0, true},
+		{"remind me at 9:30am", 9, 30, true},
+		{"remind me at 16:30", 16, 30, true},
+		{"remind me at 12am", 0, 0, true},
+		{"remind me at 12pm", 12, 0, true},
+		{"remind me tomorrow at 9", 9, 0, true},
+		{"remind me at 0am", 0, 0, false},
+		{"remind me at 13pm", 0, 0, false},
+		{"remind me at 24", 0, 0, false},
+		{"remind me at 9:75", 0, 0, false},
+		{"remind me later", 0, 0, false},
+	}
+	for _, test := range tests {
+		hour, minute, ok := parseClock(test.Text)
+		if hour != test.Hour || minute != test.Minute || ok != test.OK {
+			t.Errorf("%s: %d:%02d %t", test.Text, hour, minute, ok)
+		}
+	}
+}
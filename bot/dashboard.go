@@ -0,0 +1,281 @@
+package bot
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/18F/angrytock/helpers"
+	"github.com/18F/angrytock/tock"
+)
+
+//go:embed dashboard.html
+var dashboardPage string
+
+// dashboardTemplate renders the admin dashboard
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(dashboardPage))
+
+// dashboardUser is a late tock user on the dashboard
+type dashboardUser struct {
+	Name     string
+	Email    string
+	SlackID  string
+	Paused   bool
+	Reminded bool
+}
+
+// dashboardBother is a user on the bother watchlist on the dashboard
+type dashboardBother struct {
+	Email     string
+	Channel   string
+	Remaining int
+	TimeLeft  time.Duration
+}
+
+// dashboardCategory is a message category and its number of responses
+type dashboardCategory struct {
+	Name      string
+	Responses int
+}
+
+// dashboardSetting is a configuration value shown on the dashboard
+type dashboardSetting struct {
+	Name  string
+	Value string
+}
+
+// dashboardData holds everything the dashboard shows
+type dashboardData struct {
+	Standby    bool
+	Period     *tockPackage.ReportingPeriod
+	Mapped     []dashboardUser
+	Unmapped   []dashboardUser
+	Runs       []reminderRun
+	Bothered   []dashboardBother
+	Settings   []dashboardSetting
+	Categories []dashboardCategory
+	Tones      []string
+	Locales    []string
+	Notice     string
+	// TockError is why the late users couldn't be listed
+	TockError string
+	// DryRun lists the users a reminder run would message
+	DryRun     []dashboardUser
+	ShowDryRun bool
+	CSRFToken  string
+}
+
+// dashboard serves the admin web interface
+type dashboard struct {
+	bot      *Bot
+	username string
+	password string
+	token    string
+}
+
+// RegisterDashboard adds the admin dashboard to a mux at /admin when
+// DASHBOARD_PASSWORD is set. The dashboard uses basic authentication with
+// DASHBOARD_USERNAME, `admin` by default.
+func (bot *Bot) RegisterDashboard(mux *http.ServeMux) {
+	password := helpers.FetchCredential("DASHBOARD_PASSWORD")
+	if password == "" {
+		return
+	}
+	username := helpers.FetchCredential("DASHBOARD_USERNAME")
+	if username == "" {
+		username = "admin"
+	}
+	// The token protects the reminder buttons from requests made by other sites
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		log.Printf("Unable to start the dashboard: %s", err)
+		return
+	}
+	dash := &dashboard{bot: bot, username: username, password: password, token: hex.EncodeToString(tokenBytes)}
+	mux.HandleFunc("/admin", dash.authorized(dash.serveDashboard))
+	mux.HandleFunc("/admin/reminders", dash.authorized(dash.serveReminders))
+}
+
+// authorized checks the basic authentication credentials before handling a
+// request
+func (dash *dashboard) authorized(handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		username, password, ok := request.BasicAuth()
+		validUser := subtle.ConstantTimeCompare([]byte(username), []byte(dash.username)) == 1
+		validPassword := subtle.ConstantTimeCompare([]byte(password), []byte(dash.password)) == 1
+		if !ok || !validUser || !validPassword {
+			writer.Header().Set("WWW-Authenticate", `Basic realm="angrytock"`)
+			http.Error(writer, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(writer, request)
+	}
+}
+
+// render writes the dashboard page
+func (dash *dashboard) render(writer http.ResponseWriter, data dashboardData) {
+	data.CSRFToken = dash.token
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.Execute(writer, data); err != nil {
+		log.Print(err)
+	}
+}
+
+// serveDashboard shows the dashboard
+func (dash *dashboard) serveDashboard(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(writer, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	data := dash.bot.dashboardData()
+	if request.URL.Query().Get("sent") != "" {
+		data.Notice = "Sending reminders to the late users."
+	}
+	dash.render(writer, data)
+}
+
+// serveReminders handles the reminder buttons. A dry run shows who would be
+// messaged and sending reminds the late users like `slap users`.
+func (dash *dashboard) serveReminders(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		http.Error(writer, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := request.FormValue("token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(dash.token)) != 1 {
+		http.Error(writer, "Invalid form token, reload the dashboard", http.StatusForbidden)
+		return
+	}
+	data := dash.bot.dashboardData()
+	switch request.FormValue("mode") {
+	case "dry-run":
+		for _, user := range data.Mapped {
+			if !user.Paused {
+				data.DryRun = append(data.DryRun, user)
+			}
+		}
+		data.ShowDryRun = true
+		data.Notice = fmt.Sprintf("Dry run: %d users would be reminded. No messages were sent.", len(data.DryRun))
+		if data.TockError != "" {
+			data.Notice = "Dry run: unable to list the late users from Tock. No messages were sent."
+		}
+		dash.render(writer, data)
+	case "send":
+		if data.Standby {
+			data.Notice = "This instance is standing by, send reminders from the leader."
+			dash.render(writer, data)
+			return
+		}
+		log.Println("Reminders requested from the dashboard")
+		dash.bot.background(dash.bot.SlapLateUsers)
+		http.Redirect(writer, request, "/admin?sent=1", http.StatusSeeOther)
+	default:
+		http.Error(writer, "Unknown mode", http.StatusBadRequest)
+	}
+}
+
+// dashboardData collects what the dashboard shows
+func (bot *Bot) dashboardData() dashboardData {
+	data := dashboardData{
+		Standby:  bot.lease != nil && !bot.leading.Load(),
+		Runs:     bot.recentReminderRuns(),
+		Bothered: bot.dashboardBothered(),
+		Settings: bot.dashboardSettings(),
+		Locales:  bot.MessageRepo.Locales(),
+	}
+	current := bot.MessageRepo.Current()
+	data.Tones = current.ToneNames()
+	for name, msgs := range current.Categories {
+		data.Categories = append(data.Categories, dashboardCategory{name, len(msgs.Messages)})
+	}
+	sort.Slice(data.Categories, func(i, j int) bool {
+		return data.Categories[i].Name < data.Categories[j].Name
+	})
+	sort.Strings(data.Locales)
+
+	data.Period, _ = bot.Tock.FetchCurrentAndPreviousPeriods()
+	if data.Period == nil {
+		return data
+	}
+	err := bot.Tock.PeriodUserApplier(data.Period.StartDate, func(user tockPackage.User) {
+		lateUser := dashboardUser{Name: userDisplayName(user), Email: user.Email}
+		lateUser.SlackID = bot.UserEmailMap.Get(user.Email)
+		if lateUser.SlackID == "" {
+			data.Unmapped = append(data.Unmapped, lateUser)
+			return
+		}
+		lateUser.Paused = bot.isPaused(lateUser.SlackID)
+		lateUser.Reminded = bot.remindedUsers.Get(lateUser.SlackID) == data.Period.StartDate
+		data.Mapped = append(data.Mapped, lateUser)
+	})
+	if err != nil {
+		log.Printf("Unable to list the late users for the dashboard: %s", err)
+		data.TockError = err.Error()
+	}
+	return data
+}
+
+// dashboardBothered lists the bother watchlist sorted by email
+func (bot *Bot) dashboardBothered() []dashboardBother {
+	var bothered []dashboardBother
+	now := time.Now()
+	for user, entry := range bot.violatorUserMap.Snapshot() {
+		expiration, ok := bot.violatorUserMap.ExpiresAt(user)
+		if !ok {
+			continue
+		}
+		bothered = append(bothered, dashboardBother{
+			Email:     entry.Email,
+			Channel:   entry.Channel,
+			Remaining: entry.Remaining,
+			TimeLeft:  expiration.Sub(now).Round(time.Minute),
+		})
+	}
+	sort.Slice(bothered, func(i, j int) bool {
+		return bothered[i].Email < bothered[j].Email
+	})
+	return bothered
+}
+
+// dashboardSettings lists the schedule and other configuration
+func (bot *Bot) dashboardSettings() []dashboardSetting {
+	var digestChannels []string
+	for _, channel := range bot.digestChannels {
+		if channel.Unit != "" {
+			digestChannels = append(digestChannels, fmt.Sprintf("%s (%s)", channel.Channel, channel.Unit))
+		} else {
+			digestChannels = append(digestChannels, channel.Channel)
+		}
+	}
+	supervisors := "off"
+	if bot.supervisorNotifications {
+		supervisors = fmt.Sprintf("%s after the end of a period", bot.supervisorEscalationDelay)
+	}
+	leader := "every instance leads"
+	if bot.lease != nil {
+		leader = fmt.Sprintf("standing by as %s", bot.lease.Holder())
+		if bot.leading.Load() {
+			leader = fmt.Sprintf("leading as %s", bot.lease.Holder())
+		}
+	}
+	return []dashboardSetting{
+		{"Digest schedule", bot.DigestSchedule},
+		{"Digest channels", strings.Join(digestChannels, ", ")},
+		{"Supervisor notifications", supervisors},
+		{"Thank you messages", "every 30m"},
+		{"Personal reminders", "checked every 1m"},
+		{"Slack users update", "weekly"},
+		{"Default tone", bot.defaultTone},
+		{"Default nudge style", bot.defaultNudgeStyle},
+		{"State snapshots", fmt.Sprintf("every %s", bot.snapshotInterval)},
+		{"Leader election", leader},
+	}
+}
@@ -0,0 +1,151 @@
+package bot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/18F/angrytock/leader"
+)
+
+// newTestDashboard returns a dashboard for a test bot with two late users in
+// slack, one of them paused, and one late user who isn't in slack. It also
+// returns the bot's tock responses.
+func newTestDashboard(t *testing.T) (*dashboard, map[string]string) {
+	responses := map[string]string{
+		"/api/reporting_period_audit.json": testPeriods,
+		testAuditPath("2024-01-15"): `[
+			{"username":"ada","email":"ada@example.gov"},
+			{"username":"grace","email":"grace@example.gov"},
+			{"username":"linus","email":"linus@example.gov"}
+		]`,
+	}
+	bot := newTestBot(t, responses)
+	bot.UserEmailMap.Update("ada@example.gov", "U1")
+	bot.UserEmailMap.Update("grace@example.gov", "U2")
+	bot.pausedUsers.SetWithTTL("U2", "paused", time.Hour)
+	return &dashboard{bot: bot, username: "admin", password: "secret", token: "form-token"}, responses
+}
+
+// dashboardRequest sends a request to a dashboard handler as the admin
+func dashboardRequest(handler http.HandlerFunc, method string, form url.Values) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, "/admin/reminders", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.SetBasicAuth("admin", "secret")
+	recorder := httptest.NewRecorder()
+	handler(recorder, request)
+	return recorder
+}
+
+// Check that the dashboard requires the admin's credentials
+func TestDashboardAuthorized(t *testing.T) {
+	dash, _ := newTestDashboard(t)
+	handler := dash.authorized(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusNoContent)
+	})
+	tests := []struct {
+		Username string
+		Password string
+		Auth     bool
+		Code     int
+	}{
+		{"", "", false, http.StatusUnauthorized},
+		{"admin", "wrong", true, http.StatusUnauthorized},
+		{"someone", "secret", true, http.StatusUnauthorized},
+		{"admin", "secret", true, http.StatusNoContent},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		if test.Auth {
+			request.SetBasicAuth(test.Username, test.Password)
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		if recorder.Code != test.Code {
+			t.Errorf("%s:%s: %d", test.Username, test.Password, recorder.Code)
+		}
+		if test.Code == http.StatusUnauthorized && recorder.Header().Get("WWW-Authenticate") == "" {
+			t.Error("unauthorized responses should ask for credentials")
+		}
+	}
+}
+
+// Check the dashboard page and its late users
+func TestServeDashboard(t *testing.T) {
+	dash, responses := newTestDashboard(t)
+	recorder := dashboardRequest(dash.serveDashboard, http.MethodGet, nil)
+	body := recorder.Body.String()
+	if recorder.Code != http.StatusOK {
+		t.Fatal(recorder.Code, body)
+	}
+	for _, expected := range []string{
+		"Latest period", "2024-01-15 to 2024-01-21, 40 hours required",
+		"Late users in Slack (2)", "ada@example.gov", "Late users not found in Slack (1)", "linus@example.gov",
+		`name="token" value="form-token"`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("missing %q", expected)
+		}
+	}
+	if recorder = dashboardRequest(dash.serveDashboard, http.MethodPost, nil); recorder.Code != http.StatusMethodNotAllowed {
+		t.Error(recorder.Code)
+	}
+
+	// The late users can't be listed while tock fails
+	delete(responses, testAuditPath("2024-01-15"))
+	body = dashboardRequest(dash.serveDashboard, http.MethodGet, nil).Body.String()
+	if !strings.Contains(body, "Unable to list the late users from Tock") {
+		t.Error(body)
+	}
+}
+
+// Check the reminder buttons' form token, dry run and sending
+func TestServeReminders(t *testing.T) {
+	dash, _ := newTestDashboard(t)
+	if recorder := dashboardRequest(dash.serveReminders, http.MethodGet, nil); recorder.Code != http.StatusMethodNotAllowed {
+		t.Error(recorder.Code)
+	}
+	form := url.Values{"mode": {"dry-run"}, "token": {"wrong"}}
+	if recorder := dashboardRequest(dash.serveReminders, http.MethodPost, form); recorder.Code != http.StatusForbidden {
+		t.Error(recorder.Code)
+	}
+	form = url.Values{"mode": {"unknown"}, "token": {"form-token"}}
+	if recorder := dashboardRequest(dash.serveReminders, http.MethodPost, form); recorder.Code != http.StatusBadRequest {
+		t.Error(recorder.Code)
+	}
+
+	// Only users in slack who didn't pause reminders are listed
+	form = url.Values{"mode": {"dry-run"}, "token": {"form-token"}}
+	recorder := dashboardRequest(dash.serveReminders, http.MethodPost, form)
+	body := recorder.Body.String()
+	if recorder.Code != http.StatusOK || !strings.Contains(body, "Dry run: 1 users would be reminded") ||
+		!strings.Contains(body, "Would be reminded") {
+		t.Fatal(recorder.Code, body)
+	}
+	dryRun := body[strings.Index(body, "Would be reminded"):strings.Index(body, "Recent runs")]
+	if !strings.Contains(dryRun, "ada@example.gov") || strings.Contains(dryRun, "grace@example.gov") ||
+		strings.Contains(dryRun, "linus@example.gov") {
+		t.Error(dryRun)
+	}
+
+	// Standby instances don't send reminders
+	dash.bot.lease = leader.NewLease(filepath.Join(t.TempDir(), "leader.lease"), "test", time.Minute)
+	form = url.Values{"mode": {"send"}, "token": {"form-token"}}
+	recorder = dashboardRequest(dash.serveReminders, http.MethodPost, form)
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "send reminders from the leader") {
+		t.Error(recorder.Code, recorder.Body.String())
+	}
+
+	// The leader sends them in the background, which is dropped here since
+	// the test bot has no slack connection
+	dash.bot.leading.Store(true)
+	dash.bot.drain(0)
+	recorder = dashboardRequest(dash.serveReminders, http.MethodPost, form)
+	if recorder.Code != http.StatusSeeOther || recorder.Header().Get("Location") != "/admin?sent=1" {
+		t.Error(recorder.Code, recorder.Header())
+	}
+}
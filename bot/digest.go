@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/18F/angrytock/tock"
+)
+
+// digestChannel is a channel that receives the late digest. If Unit is set
+// only users in that tock unit are counted.
+type digestChannel struct {
+	Channel string
+	Unit    string
+}
+
+// parseDigestChannels reads a comma separated list of channels, each
+// optionally followed by a colon and a tock unit, e.g. `C1234:Engineering`
+func parseDigestChannels(setting string) []digestChannel {
+	var channels []digestChannel
+	for _, item := range splitList(setting) {
+		parts := strings.SplitN(item, ":", 2)
+		channel := digestChannel{Channel: strings.TrimSpace(parts[0])}
+		if len(parts) == 2 {
+			channel.Unit = strings.TrimSpace(parts[1])
+		}
+		channels = append(channels, channel)
+	}
+	return channels
+}
+
+// lateDigest is a summary of the late tock users for a reporting period
+type lateDigest struct {
+	Unit           string
+	Period         *tockPackage.ReportingPeriod
+	PreviousPeriod *tockPackage.ReportingPeriod
+	Names          []string
+	Total          int
+	PreviousTotal  int
+}
+
+// collectLateDigest counts the late users for the current and previous
+// reporting periods. If unit is not empty only users in that unit are
+// counted. An error is returned if tock can't list the late users.
+func (bot *Bot) collectLateDigest(unit string) (*lateDigest, error) {
+	current, previous := bot.Tock.FetchCurrentAndPreviousPeriods()
+	if current == nil {
+		return nil, tockPackage.ErrNoReportingPeriod
+	}
+	digest := &lateDigest{Unit: unit, Period: current, PreviousPeriod: previous}
+	err := bot.digestUserApplier(current.StartDate, unit, func(user tockPackage.User) {
+		digest.Total++
+		digest.Names = append(digest.Names, userDisplayName(user))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		err = bot.digestUserApplier(previous.StartDate, unit, func(user tockPackage.User) {
+			digest.PreviousTotal++
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return digest, nil
+}
+
+// digestUserApplier applies a function to the late users of a period,
+// limited to a unit when one is given
+func (bot *Bot) digestUserApplier(timePeriod string, unit string, applyFunc func(user tockPackage.User)) error {
+	if unit == "" {
+		return bot.Tock.PeriodUserApplier(timePeriod, applyFunc)
+	}
+	return bot.Tock.ProfiledUserApplier(timePeriod, func(user tockPackage.User) {
+		if user.InUnit(unit) {
+			applyFunc(user)
+		}
+	})
+}
+
+// userDisplayName returns a tock user's name without mentioning them in slack
+func userDisplayName(user tockPackage.User) string {
+	name := strings.TrimSpace(fmt.Sprintf("%s %s", user.FirstName, user.LastName))
+	if name == "" {
+		name = user.Username
+	}
+	return name
+}
+
+// trendLine describes the change in late users since the previous period
+func (digest *lateDigest) trendLine() string {
+	if digest.PreviousPeriod == nil {
+		return ""
+	}
+	previousRange := digest.PreviousPeriod.DateRange()
+	change := digest.Total - digest.PreviousTotal
+	switch {
+	case change > 0:
+		return fmt.Sprintf("Up %d from %d for %s.", change, digest.PreviousTotal, previousRange)
+	case change < 0:
+		return fmt.Sprintf("Down %d from %d for %s.", -change, digest.PreviousTotal, previousRange)
+	default:
+		return fmt.Sprintf("Same as %s.", previousRange)
+	}
+}
+
+// Message renders the digest for posting in a channel. Names are listed
+// without slack mentions so nobody gets pinged.
+func (digest *lateDigest) Message(showNames bool) string {
+	title := "Tock digest"
+	if digest.Unit != "" {
+		title = fmt.Sprintf("Tock digest for %s", digest.Unit)
+	}
+	lines := []string{
+		fmt.Sprintf("*%s, %s*", title, digest.Period.DateRange()),
+		fmt.Sprintf("%d people are late.", digest.Total),
+	}
+	if trend := digest.trendLine(); trend != "" {
+		lines = append(lines, trend)
+	}
+	if showNames && len(digest.Names) > 0 {
+		lines = append(lines, "Still missing: "+strings.Join(digest.Names, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PostLateDigest posts a summary of late users to the digest channels
+func (bot *Bot) PostLateDigest() {
+	if len(bot.digestChannels) == 0 {
+		return
+	}
+	log.Println("Posting late digest")
+	for _, channel := range bot.digestChannels {
+		digest, err := bot.collectLateDigest(channel.Unit)
+		if err != nil {
+			log.Printf("Not posting the late digest to %s: %s", channel.Channel, err)
+			continue
+		}
+		bot.Slack.MessageChannel(channel.Channel, digest.Message(bot.digestShowNames))
+	}
+}
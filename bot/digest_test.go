@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/18F/angrytock/tock"
+)
+
+// Check that digest channels are read with their optional units
+func TestParseDigestChannels(t *testing.T) {
+	tests := []struct {
+		Input  string
+		Output []digestChannel
+	}{
+		{"", nil},
+		{"C1234", []digestChannel{{Channel: "C1234"}}},
+		{
+			"C1234:Engineering, C5678 ,",
+			[]digestChannel{{Channel: "C1234", Unit: "Engineering"}, {Channel: "C5678"}},
+		},
+		{
+			"C1234 : Design Studio",
+			[]digestChannel{{Channel: "C1234", Unit: "Design Studio"}},
+		},
+		{"C1234:a:b", []digestChannel{{Channel: "C1234", Unit: "a:b"}}},
+	}
+	for _, test := range tests {
+		channels := parseDigestChannels(test.Input)
+		if !reflect.DeepEqual(channels, test.Output) {
+			t.Errorf("%q: %+v", test.Input, channels)
+		}
+	}
+}
+
+// Check the change in late users since the previous period
+func TestDigestTrendLine(t *testing.T) {
+	previous := &tockPackage.ReportingPeriod{StartDate: "2024-01-01", EndDate: "2024-01-07"}
+	tests := []struct {
+		Previous      *tockPackage.ReportingPeriod
+		Total         int
+		PreviousTotal int
+		Output        string
+	}{
+		{nil, 3, 0, ""},
+		{previous, 5, 2, "Up 3 from 2 for 2024-01-01 to 2024-01-07."},
+		{previous, 1, 4, "Down 3 from 4 for 2024-01-01 to 2024-01-07."},
+		{previous, 2, 2, "Same as 2024-01-01 to 2024-01-07."},
+	}
+	for _, test := range tests {
+		digest := &lateDigest{PreviousPeriod: test.Previous, Total: test.Total, PreviousTotal: test.PreviousTotal}
+		if line := digest.trendLine(); line != test.Output {
+			t.Error(line)
+		}
+	}
+}
+
+// Check that names are only listed when asked for and never mentioned
+func TestDigestMessage(t *testing.T) {
+	digest := &lateDigest{
+		Unit:   "Engineering",
+		Period: &tockPackage.ReportingPeriod{StartDate: "2024-01-08", EndDate: "2024-01-14"},
+		Names:  []string{"Ada Lovelace", "grace"},
+		Total:  2,
+	}
+	expected := "*Tock digest for Engineering, 2024-01-08 to 2024-01-14*\n2 people are late."
+	if message := digest.Message(false); message != expected {
+		t.Error(message)
+	}
+	if message := digest.Message(true); message != expected+"\nStill missing: Ada Lovelace, grace" {
+		t.Error(message)
+	}
+}
+
+// Check that no digest is made when tock can't list every late user
+func TestCollectLateDigestTockDown(t *testing.T) {
+	responses := map[string]string{
+		"/api/reporting_period_audit.json": testPeriods,
+		testAuditPath("2024-01-15"):        `[{"username":"ada","email":"ada@example.gov"}]`,
+	}
+	bot := newTestBot(t, responses)
+	bot.digestChannels = []digestChannel{{Channel: "C1234"}, {Channel: "C5678", Unit: "Engineering"}}
+	// The previous period's users can't be fetched
+	if digest, err := bot.collectLateDigest(""); err == nil || digest != nil {
+		t.Error(digest, err)
+	}
+	// Nothing is posted, so the missing slack connection isn't used
+	bot.PostLateDigest()
+
+	responses[testAuditPath("2024-01-08")] = `[]`
+	digest, err := bot.collectLateDigest("")
+	if err != nil || digest.Total != 1 || digest.PreviousTotal != 0 {
+		t.Error(digest, err)
+	}
+	delete(responses, "/api/reporting_period_audit.json")
+	if _, err := bot.collectLateDigest(""); err != tockPackage.ErrNoReportingPeriod {
+		t.Error(err)
+	}
+}
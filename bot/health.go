@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/18F/angrytock/metrics"
+)
+
+// tockCheckInterval is how long a tock reachability check is reused so that
+// readiness probes don't hit the tock api every time
+const tockCheckInterval = time.Minute
+
+// tockReachable checks the tock api, reusing the last result for the check
+// interval
+func (bot *Bot) tockReachable() error {
+	bot.tockCheckMutex.Lock()
+	defer bot.tockCheckMutex.Unlock()
+	if time.Since(bot.tockCheckedAt) > tockCheckInterval {
+		bot.tockCheckErr = bot.Tock.Ping()
+		bot.tockCheckedAt = time.Now()
+	}
+	return bot.tockCheckErr
+}
+
+// readinessProblems lists why the bot isn't ready to work, or nothing when
+// it is ready
+func (bot *Bot) readinessProblems() []string {
+	if bot.lease != nil && !bot.leading.Load() {
+		return []string{"standing by, another instance is leading"}
+	}
+	var problems []string
+	if !bot.connected.Load() {
+		problems = append(problems, "not connected to slack")
+	}
+	if bot.UserEmailMap.Len() == 0 {
+		problems = append(problems, "no slack users loaded")
+	}
+	if err := bot.tockReachable(); err != nil {
+		problems = append(problems, fmt.Sprintf("tock unreachable: %s", err))
+	}
+	return problems
+}
+
+// ServeReady answers readiness probes with 200 when the bot is connected to
+// slack, has loaded the slack users and can reach tock, and 503 otherwise.
+// Instances standing by for the leader lease are not ready.
+func (bot *Bot) ServeReady(writer http.ResponseWriter, request *http.Request) {
+	problems := bot.readinessProblems()
+	if len(problems) > 0 {
+		http.Error(writer, strings.Join(problems, "\n"), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(writer, "ready")
+}
+
+// registerMetrics adds gauges for the slack users and the size of every
+// saved dictionary
+func (bot *Bot) registerMetrics() {
+	metrics.Default.Describe("angrytock_users_mapped", "Slack users matched to an email")
+	metrics.Default.GaugeFunc("angrytock_users_mapped", func() float64 {
+		return float64(bot.UserEmailMap.Len())
+	})
+	metrics.Default.Describe("angrytock_slack_connected", "1 if the real time connection to slack is up")
+	metrics.Default.GaugeFunc("angrytock_slack_connected", func() float64 {
+		if bot.connected.Load() {
+			return 1
+		}
+		return 0
+	})
+	metrics.Default.Describe("angrytock_leader", "1 if this instance runs the slack connection and jobs")
+	metrics.Default.GaugeFunc("angrytock_leader", func() float64 {
+		if bot.leading.Load() {
+			return 1
+		}
+		return 0
+	})
+	metrics.Default.Describe("angrytock_cache_entries", "Entries in each of the bot's dictionaries")
+	for name, dict := range bot.stateFiles() {
+		dict := dict
+		metrics.Default.GaugeFunc("angrytock_cache_entries", func() float64 {
+			return float64(dict.Len())
+		}, "cache", strings.TrimSuffix(name, ".json"))
+	}
+	metrics.Default.Describe("angrytock_messages_sent_total", "Messages sent to slack by kind")
+	metrics.Default.Describe("angrytock_slack_errors_total", "Failed slack requests by kind")
+	metrics.Default.Describe("angrytock_tock_request_duration_seconds", "Time spent on tock api requests")
+	metrics.Default.Describe("angrytock_tock_request_errors_total", "Failed tock api requests")
+}
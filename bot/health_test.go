@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/18F/angrytock/leader"
+)
+
+// Check the reasons the bot isn't ready
+func TestReadinessProblems(t *testing.T) {
+	tests := []struct {
+		Leading   bool
+		Lease     bool
+		Connected bool
+		Users     bool
+		TockErr   error
+		Output    []string
+	}{
+		{false, false, true, true, nil, nil},
+		{true, true, true, true, nil, nil},
+		{false, true, false, false, errors.New("down"), []string{"standing by, another instance is leading"}},
+		{false, false, false, true, nil, []string{"not connected to slack"}},
+		{false, false, true, false, nil, []string{"no slack users loaded"}},
+		{
+			false, false, false, false, errors.New("timeout"),
+			[]string{"not connected to slack", "no slack users loaded", "tock unreachable: timeout"},
+		},
+	}
+	for i, test := range tests {
+		bot := newTestBot(t, map[string]string{})
+		if test.Lease {
+			bot.lease = leader.NewLease(filepath.Join(t.TempDir(), "leader.lease"), "test", time.Minute)
+		}
+		bot.leading.Store(test.Leading)
+		bot.connected.Store(test.Connected)
+		if test.Users {
+			bot.UserEmailMap.Update("ada@example.gov", "U1")
+		}
+		// Reuse a recent tock check instead of calling tock
+		bot.tockCheckedAt = time.Now()
+		bot.tockCheckErr = test.TockErr
+		if problems := bot.readinessProblems(); !reflect.DeepEqual(problems, test.Output) {
+			t.Errorf("%d: %q", i, problems)
+		}
+	}
+}
+
+// Check that tock is pinged when the last check is old and the result is
+// reused after that
+func TestTockReachable(t *testing.T) {
+	status := http.StatusOK
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests++
+		writer.WriteHeader(status)
+	}))
+	defer server.Close()
+	bot := newTestBot(t, map[string]string{})
+	bot.Tock.AuditEndpoint = server.URL + "/api/reporting_period_audit.json"
+
+	if err := bot.tockReachable(); err != nil || requests != 1 {
+		t.Error(err, requests)
+	}
+	status = http.StatusInternalServerError
+	if err := bot.tockReachable(); err != nil || requests != 1 {
+		t.Error("the last check should be reused", err, requests)
+	}
+	bot.tockCheckedAt = time.Now().Add(-2 * tockCheckInterval)
+	if err := bot.tockReachable(); err == nil || requests != 2 {
+		t.Error("tock errors should be reported", err, requests)
+	}
+}
+
+// Check the status codes of readiness probes
+func TestServeReady(t *testing.T) {
+	bot := newTestBot(t, map[string]string{})
+	bot.tockCheckedAt = time.Now()
+	recorder := httptest.NewRecorder()
+	bot.ServeReady(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Error(recorder.Code, recorder.Body.String())
+	}
+
+	bot.connected.Store(true)
+	bot.UserEmailMap.Update("ada@example.gov", "U1")
+	recorder = httptest.NewRecorder()
+	bot.ServeReady(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "ready\n" {
+		t.Error(recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,130 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/18F/angrytock/tock"
+)
+
+// historyPeriodCount is the number of ended reporting periods used for
+// streaks and the leaderboard
+const historyPeriodCount = 12
+
+// leaderboardSize is the number of units listed on the leaderboard
+const leaderboardSize = 10
+
+// periodsText writes a number of reporting periods, e.g. `1 period`
+func periodsText(count int) string {
+	if count == 1 {
+		return "1 period"
+	}
+	return fmt.Sprintf("%d periods", count)
+}
+
+// peopleText writes a number of people, e.g. `1 person`
+func peopleText(count int) string {
+	if count == 1 {
+		return "1 person"
+	}
+	return fmt.Sprintf("%d people", count)
+}
+
+// streakMessage tells a user how many periods in a row they have been on time
+func (bot *Bot) streakMessage(slackUserID string) string {
+	history, err := bot.Tock.FetchHistory(historyPeriodCount)
+	if err != nil {
+		return fmt.Sprintf("<@%s>, I couldn't get your Tock history, try again later :(", slackUserID)
+	}
+	if len(history) == 0 {
+		return fmt.Sprintf("<@%s>, I couldn't find any reporting periods in Tock :(", slackUserID)
+	}
+	streak := tockPackage.Streak(history, func(user tockPackage.User) bool {
+		return bot.UserEmailMap.Get(user.Email) == slackUserID
+	})
+	switch {
+	case streak == 0:
+		return fmt.Sprintf("<@%s>, you're late this period, so no streak yet. Fill out Tock to start one!", slackUserID)
+	case streak == len(history):
+		return fmt.Sprintf("<@%s>, you've been on time for all of the last %s! ^_^", slackUserID, periodsText(streak))
+	default:
+		return fmt.Sprintf("<@%s>, you've been on time %s in a row!", slackUserID, periodsText(streak))
+	}
+}
+
+// leaderboardMessage ranks tock units by their share of on time timecards
+func (bot *Bot) leaderboardMessage() string {
+	history, err := bot.Tock.FetchHistory(historyPeriodCount)
+	if err != nil {
+		return "I couldn't get the Tock data for the leaderboard, try again later :("
+	}
+	userData, err := bot.Tock.FetchUserData()
+	if err != nil {
+		return "I couldn't get the Tock data for the leaderboard, try again later :("
+	}
+	rates := tockPackage.UnitOnTimeRates(history, userData)
+	if len(rates) == 0 {
+		return "I don't have enough Tock data for a leaderboard yet."
+	}
+	if len(rates) > leaderboardSize {
+		rates = rates[:leaderboardSize]
+	}
+	lines := []string{fmt.Sprintf("*On time leaderboard for the last %s*", periodsText(len(history)))}
+	for idx, rate := range rates {
+		lines = append(lines, fmt.Sprintf(
+			"%d. %s: %.0f%% on time (%s)", idx+1, rate.Unit, rate.OnTime, peopleText(rate.Members),
+		))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// thankYouMessage uses the ThankYouMessages category of the user's messages,
+// or of the default messages when a translation doesn't have one, so a
+// message is never in two languages. Without the category anywhere a nice
+// message from the default messages is used.
+func (bot *Bot) thankYouMessage(userID string) string {
+	tone := bot.toneFor(userID, "")
+	if thankYou := bot.messagesFor(userID).Category("ThankYouMessages"); thankYou != nil {
+		return thankYou.GenerateMessage(bot.messageData(userID), tone.Tags...)
+	}
+	current := bot.MessageRepo.Current()
+	if thankYou := current.Category("ThankYouMessages"); thankYou != nil {
+		return thankYou.GenerateMessage(bot.messageData(userID), tone.Tags...)
+	}
+	return fmt.Sprintf(
+		"Thanks for filling out your timesheet! %s",
+		current.Nice.GenerateMessage(bot.messageData(userID), tone.Tags...),
+	)
+}
+
+// ThankRemindedUsers sends a nice message to reminded users who have since
+// filled out their timesheet. Periods whose late users can't be fetched are
+// skipped.
+func (bot *Bot) ThankRemindedUsers() {
+	// Group reminded users by the period they were reminded about
+	remindedByPeriod := make(map[string][]string)
+	for _, userID := range bot.remindedUsers.Keys() {
+		timePeriod := bot.remindedUsers.Get(userID)
+		remindedByPeriod[timePeriod] = append(remindedByPeriod[timePeriod], userID)
+	}
+	for timePeriod, userIDs := range remindedByPeriod {
+		stillLate := make(map[string]bool)
+		err := bot.Tock.PeriodUserApplier(timePeriod, func(user tockPackage.User) {
+			stillLate[bot.UserEmailMap.Get(user.Email)] = true
+		})
+		if err != nil {
+			// Try again on the next run rather than thank users who are late
+			log.Printf("Unable to check who is still late for %s: %s", timePeriod, err)
+			continue
+		}
+		for _, userID := range userIDs {
+			if stillLate[userID] {
+				continue
+			}
+			log.Printf("Thanking %s for filling out Tock", userID)
+			bot.Slack.MessageUser(userID, bot.thankYouMessage(userID))
+			bot.remindedUsers.Delete(userID)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/18F/angrytock/messages"
+)
+
+// Check the streaks of users late in the latest period, an older period and
+// no period
+func TestStreakMessage(t *testing.T) {
+	bot := newTestBot(t, map[string]string{
+		"/api/reporting_period_audit.json": testPeriods,
+		testAuditPath("2024-01-15"):        `[{"username":"ada","email":"ada@example.gov"}]`,
+		testAuditPath("2024-01-08"):        `[{"username":"grace","email":"grace@example.gov"}]`,
+		testAuditPath("2024-01-01"):        `[{"username":"ada","email":"ada@example.gov"}]`,
+	})
+	bot.UserEmailMap.Update("ada@example.gov", "U1")
+	bot.UserEmailMap.Update("grace@example.gov", "U2")
+	bot.UserEmailMap.Update("linus@example.gov", "U3")
+	tests := []struct {
+		User   string
+		Output string
+	}{
+		{"U1", "<@U1>, you're late this period, so no streak yet. Fill out Tock to start one!"},
+		{"U2", "<@U2>, you've been on time 1 period in a row!"},
+		{"U3", "<@U3>, you've been on time for all of the last 3 periods! ^_^"},
+	}
+	for _, test := range tests {
+		if message := bot.streakMessage(test.User); message != test.Output {
+			t.Error(message)
+		}
+	}
+}
+
+// Check that streaks and the leaderboard aren't made up while tock can't list
+// the late users of every period
+func TestHistoryTockDown(t *testing.T) {
+	bot := newTestBot(t, map[string]string{
+		"/api/reporting_period_audit.json": testPeriods,
+		testAuditPath("2024-01-15"):        `[{"username":"ada","email":"ada@example.gov"}]`,
+		"/api/user_data.json":              `[{"user":"ada","unit":"Engineering","current_employee":true}]`,
+	})
+	bot.UserEmailMap.Update("grace@example.gov", "U2")
+	if message := bot.streakMessage("U2"); message != "<@U2>, I couldn't get your Tock history, try again later :(" {
+		t.Error(message)
+	}
+	if message := bot.leaderboardMessage(); !strings.HasPrefix(message, "I couldn't get the Tock data") {
+		t.Error(message)
+	}
+	if message := newTestBot(t, nil).streakMessage("U2"); !strings.Contains(message, "couldn't find any reporting periods") {
+		t.Error(message)
+	}
+}
+
+// Check that thank you messages are in the user's language and never start
+// in english
+func TestThankYouMessage(t *testing.T) {
+	bot := newTestBot(t, nil)
+	bot.localePreferences.Update("U1", "es")
+	spanish := bot.MessageRepo.Localized("es").Category("ThankYouMessages")
+	english := bot.MessageRepo.Current().Category("ThankYouMessages")
+	if message := bot.thankYouMessage("U1"); !containsMessage(spanish, "U1", message) {
+		t.Error(message)
+	}
+	if message := bot.thankYouMessage("U2"); !containsMessage(english, "U2", message) {
+		t.Error(message)
+	}
+}
+
+// containsMessage checks if a message is one of the responses of a category,
+// written for a user
+func containsMessage(msgs *messagesPackage.MessageArray, userID string, message string) bool {
+	for _, response := range msgs.Messages {
+		if strings.ReplaceAll(response.Text, "{{.UserMention}}", "<@"+userID+">") == message {
+			return true
+		}
+	}
+	return false
+}
+
+// Check that nobody is thanked while tock can't say who is still late
+func TestThankRemindedUsersTockDown(t *testing.T) {
+	bot := newTestBot(t, map[string]string{})
+	bot.UserEmailMap.Update("ada@example.gov", "U1")
+	bot.remindedUsers.Update("U1", "2024-01-15")
+	// Thanking U1 would need slack, which the test bot doesn't have
+	bot.ThankRemindedUsers()
+	if bot.remindedUsers.Get("U1") != "2024-01-15" {
+		t.Error("reminded users should be kept when tock is down")
+	}
+}
+
+// Check that the leaderboard ranks units by their on time rate
+func TestLeaderboardMessage(t *testing.T) {
+	bot := newTestBot(t, map[string]string{
+		"/api/reporting_period_audit.json": testPeriods,
+		testAuditPath("2024-01-15"):        `[{"username":"ada","email":"ada@example.gov"}]`,
+		testAuditPath("2024-01-08"):        `[]`,
+		testAuditPath("2024-01-01"):        `[]`,
+		"/api/user_data.json": `[
+			{"user":"ada","unit":"Engineering","current_employee":true},
+			{"user":"grace","unit":"Design","current_employee":true}
+		]`,
+	})
+	message := bot.leaderboardMessage()
+	expected := "*On time leaderboard for the last 3 periods*\n" +
+		"1. Design: 100% on time (1 person)\n" +
+		"2. Engineering: 67% on time (1 person)"
+	if message != expected {
+		t.Error(message)
+	}
+	empty := newTestBot(t, map[string]string{"/api/user_data.json": `[]`})
+	if message := empty.leaderboardMessage(); !strings.HasPrefix(message, "I don't have enough") {
+		t.Error(message)
+	}
+	if message := newTestBot(t, nil).leaderboardMessage(); !strings.HasPrefix(message, "I couldn't get the Tock data") {
+		t.Error(message)
+	}
+}
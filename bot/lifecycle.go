@@ -0,0 +1,309 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/robfig/cron"
+)
+
+// drainTimeout is how long the bot waits for background work when stopping
+const drainTimeout = 30 * time.Second
+
+// minLeaseDuration is the shortest leader lease. The lease is renewed every
+// third of its duration, which has to leave time to reach the lease file.
+const minLeaseDuration = 3 * time.Second
+
+var (
+	// ErrInvalidAuth is returned by Run when slack rejects the bot's token
+	ErrInvalidAuth = errors.New("slack rejected the bot's credentials")
+	// ErrLostLeadership is returned by Run when another instance took the
+	// leader lease
+	ErrLostLeadership = errors.New("another instance took the leader lease")
+)
+
+// rtmConnection is the real time connection to slack
+type rtmConnection interface {
+	// ManageConnection connects and reconnects until Disconnect is called
+	ManageConnection()
+	Disconnect() error
+	Events() <-chan slack.RTMEvent
+}
+
+// leaderSlack sends to slack only while the bot holds the leader lease, so
+// work still running after the lease was lost doesn't send next to the new
+// leader
+type leaderSlack struct {
+	slackClient
+	bot *Bot
+}
+
+// sending returns if the bot can send to slack and logs dropped sends
+func (api leaderSlack) sending(kind string, to string) bool {
+	if api.bot.leading.Load() {
+		return true
+	}
+	log.Printf("Not sending the %s to %s, this instance isn't leading", kind, to)
+	return false
+}
+
+func (api leaderSlack) SendToChannel(channelID string, message string) {
+	if api.sending("message", channelID) {
+		api.slackClient.SendToChannel(channelID, message)
+	}
+}
+
+func (api leaderSlack) MessageUser(user string, message string) {
+	if api.sending("message", user) {
+		api.slackClient.MessageUser(user, message)
+	}
+}
+
+func (api leaderSlack) MessageChannel(channelID string, message string) {
+	if api.sending("message", channelID) {
+		api.slackClient.MessageChannel(channelID, message)
+	}
+}
+
+func (api leaderSlack) MessageUserEphemeral(channelID string, user string, message string) {
+	if api.sending("message", user) {
+		api.slackClient.MessageUserEphemeral(channelID, user, message)
+	}
+}
+
+func (api leaderSlack) ReactToMessage(channelID string, timestamp string, emoji string) {
+	if api.sending("reaction", channelID) {
+		api.slackClient.ReactToMessage(channelID, timestamp, emoji)
+	}
+}
+
+func (api leaderSlack) ReplyInThread(channelID string, threadTimestamp string, message string) {
+	if api.sending("reply", channelID) {
+		api.slackClient.ReplyInThread(channelID, threadTimestamp, message)
+	}
+}
+
+// background runs a task in a goroutine that the bot waits for when it
+// stops. Tasks started after the bot began stopping are dropped.
+func (bot *Bot) background(task func()) {
+	bot.lifecycleLock.Lock()
+	defer bot.lifecycleLock.Unlock()
+	if bot.stopping {
+		return
+	}
+	bot.inFlight.Add(1)
+	go func() {
+		defer bot.inFlight.Done()
+		task()
+	}()
+}
+
+// scheduleJobs creates the cron for the bot's recurring work
+func (bot *Bot) scheduleJobs() *cron.Cron {
+	c := cron.New()
+	// Update the list of stored slack users weekly
+	c.AddFunc("@weekly", func() {
+		bot.background(bot.StoreSlackUsers)
+	})
+	// Post the late digest to the configured channels
+	err := c.AddFunc(bot.DigestSchedule, func() {
+		bot.background(bot.PostLateDigest)
+	})
+	if err != nil {
+		log.Printf("Invalid DIGEST_SCHEDULE %q, the digest won't be posted: %s", bot.DigestSchedule, err)
+	}
+	// Check hourly if supervisors should hear about late reports
+	c.AddFunc("@hourly", func() {
+		bot.background(bot.NotifySupervisors)
+	})
+	// Thank users who filled out tock after being reminded
+	c.AddFunc("@every 30m", func() {
+		bot.background(bot.ThankRemindedUsers)
+	})
+	// Send the reminders users asked for
+	c.AddFunc("@every 1m", func() {
+		bot.background(bot.SendPersonalReminders)
+	})
+	return c
+}
+
+// Run starts the scheduled jobs, the connection to slack and the message
+// file watcher and handles slack events until the context is done. It then
+// stops the jobs, waits for background work such as reminder sends,
+// disconnects from slack, stops the watcher and saves the state.
+// ErrInvalidAuth is returned if slack rejects the token.
+//
+// With leader election the bot stands by until it holds the lease and
+// returns ErrLostLeadership if another instance takes the lease over.
+func (bot *Bot) Run(ctx context.Context) error {
+	// Standby instances keep their messages current for the dashboard
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	defer stopWatching()
+	bot.MessageRepo.Watch(watchCtx, bot.messagesReload)
+	if bot.lease == nil {
+		bot.leading.Store(true)
+		err := bot.lead(ctx)
+		bot.SaveState()
+		bot.closeState()
+		return err
+	}
+	if !bot.waitForLease(ctx) {
+		bot.closeState()
+		return nil
+	}
+	// Leading starts before the renewals, which stop it when the lease is lost
+	bot.leading.Store(true)
+	leaderCtx, cancel := context.WithCancel(ctx)
+	lost := make(chan struct{})
+	go bot.renewLease(leaderCtx, cancel, lost)
+	err := bot.lead(leaderCtx)
+	cancel()
+	select {
+	case <-lost:
+		// The new leader owns the state now
+		bot.closeState()
+		if err == nil {
+			err = ErrLostLeadership
+		}
+		return err
+	default:
+	}
+	bot.SaveState()
+	bot.closeState()
+	if releaseErr := bot.lease.Release(); releaseErr != nil {
+		log.Printf("Unable to release the leader lease: %s", releaseErr)
+	}
+	return err
+}
+
+// lead loads the saved state, starts the jobs and the connection to slack
+// and handles events until the context is done. Run marks the bot as leading
+// first.
+func (bot *Bot) lead(ctx context.Context) error {
+	defer bot.leading.Store(false)
+	if bot.lease != nil {
+		// Pick up the state the previous leader saved
+		bot.restoreState()
+	}
+	// Slack users restored from STATE_DIR are refreshed by the weekly update
+	if bot.UserEmailMap.Len() == 0 {
+		bot.StoreSlackUsers()
+		bot.SaveState()
+	}
+	bot.startSnapshots(bot.snapshotInterval)
+
+	jobs := bot.scheduleJobs()
+	jobs.Start()
+	go bot.rtm.ManageConnection()
+
+	err := bot.listen(ctx)
+
+	log.Println("Stopping")
+	jobs.Stop()
+	if bot.lease != nil && !bot.leading.Load() {
+		// The lease was lost, so another instance may be talking to slack
+		// already. Disconnect first, sends are dropped while draining.
+		bot.disconnect()
+		bot.drain(drainTimeout)
+		return err
+	}
+	bot.drain(drainTimeout)
+	bot.disconnect()
+	return err
+}
+
+// disconnect closes the real time connection to slack
+func (bot *Bot) disconnect() {
+	if err := bot.rtm.Disconnect(); err != nil {
+		log.Printf("Unable to disconnect from slack: %s", err)
+	}
+	bot.connected.Store(false)
+}
+
+// waitForLease stands by until this instance holds the leader lease. It
+// returns false if the context is done first.
+func (bot *Bot) waitForLease(ctx context.Context) bool {
+	ticker := time.NewTicker(bot.lease.Duration() / 3)
+	defer ticker.Stop()
+	logged := false
+	for {
+		acquired, err := bot.lease.TryAcquire(time.Now())
+		switch {
+		case err != nil:
+			log.Printf("Unable to check the leader lease: %s", err)
+		case acquired:
+			log.Printf("Leading as %s", bot.lease.Holder())
+			return true
+		case !logged:
+			log.Println("Another instance is leading, standing by")
+			logged = true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// renewLease keeps the leader lease until the context is done. If another
+// instance took the lease, or the lease couldn't be renewed for two thirds of
+// its duration, it stops leading, closes lost and cancels the leader's
+// context. Stepping down before the lease expires leaves the rest of it to
+// stop sending before another instance can take over. Errors before then are
+// retried on the next renewal.
+func (bot *Bot) renewLease(ctx context.Context, cancel context.CancelFunc, lost chan struct{}) {
+	stepDown := bot.lease.Duration() - bot.lease.Duration()/3
+	ticker := time.NewTicker(bot.lease.Duration() / 3)
+	defer ticker.Stop()
+	deadline := time.NewTimer(stepDown)
+	defer deadline.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			log.Printf("Lost the leader lease, it couldn't be renewed for %s", stepDown)
+		case <-ticker.C:
+			acquired, err := bot.lease.TryAcquire(time.Now())
+			if err != nil {
+				log.Printf("Unable to renew the leader lease, retrying: %s", err)
+				continue
+			}
+			if acquired {
+				if !deadline.Stop() {
+					<-deadline.C
+				}
+				deadline.Reset(stepDown)
+				continue
+			}
+			log.Println("Lost the leader lease to another instance")
+		}
+		bot.leading.Store(false)
+		close(lost)
+		cancel()
+		return
+	}
+}
+
+// drain stops new background work and waits up to timeout for the work
+// already running
+func (bot *Bot) drain(timeout time.Duration) {
+	bot.lifecycleLock.Lock()
+	bot.stopping = true
+	bot.lifecycleLock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		bot.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Printf("Stopped waiting for background work after %s", timeout)
+	}
+}
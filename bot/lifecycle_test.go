@@ -0,0 +1,228 @@
+package bot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/18F/angrytock/leader"
+	"github.com/nlopes/slack"
+)
+
+// fakeRTM is a real time connection that sends the events the tests give it
+type fakeRTM struct {
+	events       chan slack.RTMEvent
+	managed      chan struct{}
+	disconnected atomic.Bool
+}
+
+func newFakeRTM() *fakeRTM {
+	return &fakeRTM{events: make(chan slack.RTMEvent, 10), managed: make(chan struct{})}
+}
+
+func (rtm *fakeRTM) ManageConnection() {
+	close(rtm.managed)
+}
+
+func (rtm *fakeRTM) Disconnect() error {
+	rtm.disconnected.Store(true)
+	return nil
+}
+
+func (rtm *fakeRTM) Events() <-chan slack.RTMEvent {
+	return rtm.events
+}
+
+// newRunBot returns a test bot that can run without slack, tock or saved
+// slack users
+func newRunBot(t *testing.T) (*Bot, *fakeRTM) {
+	bot := newTestBot(t, map[string]string{})
+	rtm := newFakeRTM()
+	bot.rtm = rtm
+	bot.DigestSchedule = "0 0 10 * * MON"
+	bot.snapshotInterval = time.Hour
+	bot.stateDir = t.TempDir()
+	// Saved users keep the bot from fetching them from slack
+	bot.UserEmailMap.Update("ada@example.gov", "U1")
+	return bot, rtm
+}
+
+// Check that cancelling the context stops the bot after its background work
+// finishes and that the state is saved afterwards
+func TestRunStops(t *testing.T) {
+	bot, rtm := newRunBot(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan error)
+	go func() {
+		stopped <- bot.Run(ctx)
+	}()
+	<-rtm.managed
+
+	release := make(chan struct{})
+	bot.background(func() {
+		<-release
+		bot.remindedUsers.Update("U1", "2024-01-15")
+	})
+	cancel()
+	select {
+	case err := <-stopped:
+		t.Fatal("Run returned before the background work finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return after the context was cancelled")
+	}
+
+	if !rtm.disconnected.Load() || bot.connected.Load() {
+		t.Error("the bot should disconnect from slack")
+	}
+	saved, err := os.ReadFile(filepath.Join(bot.stateDir, "reminded_users.json"))
+	if err != nil || !strings.Contains(string(saved), "2024-01-15") {
+		t.Error("the state should be saved after the background work", string(saved), err)
+	}
+	// Work started after stopping is dropped
+	started := false
+	bot.background(func() {
+		started = true
+	})
+	bot.inFlight.Wait()
+	if started {
+		t.Error("background work should be dropped after stopping")
+	}
+}
+
+// Check that Run returns ErrInvalidAuth when slack rejects the token
+func TestRunInvalidAuth(t *testing.T) {
+	bot, rtm := newRunBot(t)
+	rtm.events <- slack.RTMEvent{Type: "connected", Data: &slack.ConnectedEvent{}}
+	rtm.events <- slack.RTMEvent{Type: "invalid_auth", Data: &slack.InvalidAuthEvent{}}
+	stopped := make(chan error)
+	go func() {
+		stopped <- bot.Run(context.Background())
+	}()
+	select {
+	case err := <-stopped:
+		if err != ErrInvalidAuth {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return after an invalid auth event")
+	}
+	if !rtm.disconnected.Load() || bot.connected.Load() {
+		t.Error("the bot should disconnect from slack")
+	}
+}
+
+// Check that the leader keeps going through lease errors and steps down
+// before the lease expires, and stops right away when another instance has
+// the lease
+func TestRenewLease(t *testing.T) {
+	duration := 600 * time.Millisecond
+	leaseDir := filepath.Join(t.TempDir(), "shared")
+	if err := os.Mkdir(leaseDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	leasePath := filepath.Join(leaseDir, "leader.lease")
+	bot := newTestBot(t, map[string]string{})
+	bot.lease = leader.NewLease(leasePath, "first", duration)
+	if acquired, err := bot.lease.TryAcquire(time.Now()); !acquired || err != nil {
+		t.Fatal(acquired, err)
+	}
+
+	// The lease file can't be reached while the shared storage is gone
+	if err := os.RemoveAll(leaseDir); err != nil {
+		t.Fatal(err)
+	}
+	bot.leading.Store(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	lost := make(chan struct{})
+	started := time.Now()
+	go bot.renewLease(ctx, cancel, lost)
+	select {
+	case <-lost:
+		t.Fatal("the lease was lost before the first failed renewal was retried")
+	case <-time.After(duration / 2):
+	}
+	select {
+	case <-lost:
+		if elapsed := time.Since(started); elapsed >= duration {
+			t.Error("the leader should step down before the lease expires", elapsed)
+		}
+	case <-time.After(5 * duration):
+		t.Fatal("the lease should be lost once it couldn't be renewed")
+	}
+	if ctx.Err() == nil || bot.leading.Load() {
+		t.Error("the leader's context should be cancelled and it should stop leading")
+	}
+
+	// Another instance took the lease
+	if err := os.Mkdir(leaseDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	other := leader.NewLease(leasePath, "second", time.Hour)
+	if acquired, err := other.TryAcquire(time.Now()); !acquired || err != nil {
+		t.Fatal(acquired, err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	lost = make(chan struct{})
+	go bot.renewLease(ctx, cancel, lost)
+	select {
+	case <-lost:
+	case <-time.After(duration / 2):
+		t.Fatal("the lease should be lost at the first renewal")
+	}
+}
+
+// Check that a leader that lost the lease disconnects and drops sends from
+// the background work it waits for
+func TestRunLostLease(t *testing.T) {
+	bot, rtm := newRunBot(t)
+	api := &fakeSlack{}
+	bot.Slack = leaderSlack{slackClient: api, bot: bot}
+	leasePath := filepath.Join(t.TempDir(), "leader.lease")
+	bot.lease = leader.NewLease(leasePath, "first", 300*time.Millisecond)
+	stopped := make(chan error)
+	go func() {
+		stopped <- bot.Run(context.Background())
+	}()
+	<-rtm.managed
+
+	release := make(chan struct{})
+	bot.background(func() {
+		<-release
+		bot.Slack.MessageUser("U1", "you're late")
+	})
+	other := leader.NewLease(leasePath, "second", time.Hour)
+	if _, err := other.TryAcquire(time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	for wait := time.Now(); !rtm.disconnected.Load(); time.Sleep(10 * time.Millisecond) {
+		if time.Since(wait) > 5*time.Second {
+			t.Fatal("the bot should disconnect before waiting for background work")
+		}
+	}
+	close(release)
+	select {
+	case err := <-stopped:
+		if err != ErrLostLeadership {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return after the lease was lost")
+	}
+	if len(api.sent) != 0 {
+		t.Error("nothing should be sent after the lease was lost", api.sent)
+	}
+}
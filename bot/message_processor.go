@@ -2,23 +2,41 @@ package bot
 
 import (
 	"fmt"
+	"log"
 	"math/rand"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/nlopes/slack"
 )
 
+// toneCommand and languageCommand find the tone and language commands as
+// words, so that `milestone` is not a tone command
+var (
+	toneCommand     = regexp.MustCompile(`\btone\s+\w+`)
+	languageCommand = regexp.MustCompile(`\blanguage\s+\S+`)
+)
+
 const panopticon = "'The [tockers] must never know whether [they are] looked at at any one moment; but [they] must be sure that [they] may always be so' - Foucault, Discipline 201"
 
 // processMessage handles incomming messages
 func (bot *Bot) processMessage(message *slack.MessageEvent) {
 	user := message.User
 	botID := bot.Slack.GetSelfID()
+	if !bot.shouldHandle(message, botID) {
+		return
+	}
+	// Direct messages are commands without a mention and never get nudges
+	if isDirectMessage(message.Channel) {
+		bot.directMessage(message, user, botID)
+		return
+	}
 	// Handle Violators
-	userID := bot.violatorUserMap.Get(user)
-	if userID != "" {
-		bot.violatorMessage(message, user)
+	entry, ok := bot.violatorUserMap.GetOK(user)
+	if ok && !bot.isPaused(user) && shouldBother(entry, message.Channel, time.Now()) {
+		bot.violatorMessage(message, user, entry)
 	}
 
 	botCalled := strings.HasPrefix(
@@ -27,6 +45,20 @@ func (bot *Bot) processMessage(message *slack.MessageEvent) {
 	)
 	if botCalled { // Messages made directly to bot
 		switch {
+		// Personal reminders are for everyone
+		case mentionedCommand(message.Text, botID, "remind me"):
+			{
+				bot.reply(message, bot.scheduleReminder(message.Text, user, time.Now()))
+			}
+		case mentionedCommand(message.Text, botID, "cancel reminders"):
+			{
+				bot.reply(message, bot.cancelReminders(user))
+			}
+		// Admins get the commands for everyone too, like in direct messages
+		case startsWithCommand(commandText(message.Text, botID), niceCommands):
+			{
+				bot.niceMessage(message, user)
+			}
 		case bot.isMasterUser(user):
 			{
 				bot.masterMessages(message)
@@ -41,9 +73,7 @@ func (bot *Bot) processMessage(message *slack.MessageEvent) {
 		// Messages that contain the word tick
 		case strings.Contains(message.Text, " tick "):
 			{
-				bot.Slack.SendMessage(bot.Slack.NewOutgoingMessage(
-					"tock", message.Channel,
-				))
+				bot.reply(message, "tock")
 			}
 		// Messages that references the bot will be send out 30% of the time. See: Foucault, Discipline 201
 		case strings.Contains(message.Text, fmt.Sprintf("<@%s>", botID)):
@@ -51,43 +81,77 @@ func (bot *Bot) processMessage(message *slack.MessageEvent) {
 				var returnMessage string
 				randomInt := rand.Intn(100)
 				if randomInt >= 70 {
-					returnMessage = bot.MessageRepo.Nice.GenerateMessage(user)
+					tone := bot.toneFor(user, message.Channel)
+					returnMessage = tone.Nice.GenerateMessage(bot.messageData(user), tone.Tags...)
 				} else if randomInt <= 3 {
 					returnMessage = panopticon
 				}
-				bot.Slack.SendMessage(bot.Slack.NewOutgoingMessage(
-					returnMessage,
-					message.Channel,
-				))
+				bot.reply(message, returnMessage)
 			}
 		}
 	}
 }
 
-// violatorMessage has the message for a late user
-func (bot *Bot) violatorMessage(message *slack.MessageEvent, user string) {
-	var returnMessage string
+// mentionedCommand checks if a message starts with the bot's mention
+// followed by a command, e.g. `<@U1234>: remind me`
+func mentionedCommand(text string, botID string, command string) bool {
+	commandFinder := regexp.MustCompile(fmt.Sprintf(
+		`^<@%s>:?\s+%s\b`, regexp.QuoteMeta(botID), regexp.QuoteMeta(command),
+	))
+	return commandFinder.MatchString(text)
+}
+
+// violatorMessage has the message for a late user on the watchlist
+func (bot *Bot) violatorMessage(message *slack.MessageEvent, user string, entry botherEntry) {
 	// Check if user is still late
-	if bot.isLateUser(user) {
-		returnMessage = bot.MessageRepo.Angry.GenerateMessage(user)
-	} else {
-		returnMessage = fmt.Sprintf(
+	late, err := bot.isLateUser(user)
+	if err != nil {
+		log.Printf("Unable to check if %s is late: %s", user, err)
+		return
+	}
+	if !late {
+		bot.violatorUserMap.Delete(user)
+		// Reactions are only for late users
+		if bot.nudgeStyleFor(message.Channel) == "reaction" {
+			return
+		}
+		bot.nudge(message, user, fmt.Sprintf(
 			"<@%s>, I was about to yell at you, but then I realized you actually filled out your timesheet. Thanks! ^_^",
 			user,
-		)
+		))
+		return
 	}
-	bot.violatorUserMap.Delete(user)
-	bot.Slack.SendMessage(bot.Slack.NewOutgoingMessage(returnMessage, message.Channel))
+	tone := bot.toneFor(user, message.Channel)
+	bot.nudge(message, user, tone.Angry.GenerateMessage(bot.messageData(user), tone.Tags...))
+	bot.recordBother(user, entry, time.Now())
 }
 
 // masterMessages contains the commands for admins
 func (bot *Bot) masterMessages(message *slack.MessageEvent) {
 	var returnMessage string
 	botID := bot.Slack.GetSelfID()
+	unitFinder := regexp.MustCompile(`(?:slap users|who is late) in ([^?!]+)`)
+	unitMatch := unitFinder.FindStringSubmatch(message.Text)
 	switch {
+	case unitMatch != nil && strings.Contains(message.Text, "slap users"):
+		{
+			unit := strings.TrimSpace(unitMatch[1])
+			bot.background(func() { bot.SlapUnitUsers(unit) })
+			returnMessage = fmt.Sprintf("Slapping Users in %s!", unit)
+		}
+	case unitMatch != nil && strings.Contains(message.Text, "who is late"):
+		{
+			unit := strings.TrimSpace(unitMatch[1])
+			lateList, total, err := bot.fetchLateUsers(unit)
+			if err != nil {
+				returnMessage = fmt.Sprintf("I couldn't check Tock for late users in %s, try again later :(", unit)
+			} else {
+				returnMessage = fmt.Sprintf("%s are late in %s! %d people total.", lateList, unit, total)
+			}
+		}
 	case strings.Contains(message.Text, "slap users"):
 		{
-			go bot.SlapLateUsers()
+			bot.background(bot.SlapLateUsers)
 			returnMessage = "Slapping Users!"
 		}
 	case strings.Contains(message.Text, "remind users"):
@@ -98,24 +162,73 @@ func (bot *Bot) masterMessages(message *slack.MessageEvent) {
 				returnMessage = "Error: no message to send or message not formatted correctly"
 			} else {
 				messageToSend := strings.Trim(foundMessages[0], "{}")
-				go bot.RemindUsers(messageToSend)
+				bot.background(func() { bot.RemindUsers(messageToSend) })
 				returnMessage = fmt.Sprintf("Reminding users with `%s`", messageToSend)
 			}
 		}
+	case strings.Contains(message.Text, "bother status"):
+		{
+			returnMessage = bot.botherStatus()
+		}
+	case strings.Contains(message.Text, "stop bothering"):
+		{
+			returnMessage = bot.stopBothering(message.Text)
+		}
 	case strings.Contains(message.Text, "bother users"):
 		{
-			bot.startviolatorUserMapUpdater()
-			returnMessage = "Starting to bother users!"
+			settings, err := parseBotherSettings(message.Text)
+			if err != nil {
+				returnMessage = err.Error()
+			} else {
+				bot.startviolatorUserMapUpdater(settings)
+				returnMessage = fmt.Sprintf("Starting to bother users %s!", settings.describe())
+			}
 		}
 	case strings.Contains(message.Text, "who is late?"):
 		{
-			lateList, total := bot.fetchLateUsers()
-			returnMessage = fmt.Sprintf("%s are late! %d people total.", lateList, total)
+			lateList, total, err := bot.fetchLateUsers("")
+			if err != nil {
+				returnMessage = "I couldn't check Tock for late users, try again later :("
+			} else {
+				returnMessage = fmt.Sprintf("%s are late! %d people total.", lateList, total)
+			}
+		}
+	case strings.Contains(message.Text, "nudge style"):
+		{
+			returnMessage = bot.setNudgeStyle(message.Text, message.Channel)
+		}
+	case strings.Contains(message.Text, "channel tone"):
+		{
+			returnMessage = bot.setChannelTone(message.Text, message.Channel)
+		}
+	case toneCommand.MatchString(message.Text):
+		{
+			returnMessage = bot.setUserTone(message.Text, message.User)
+		}
+	case strings.Contains(message.Text, "reload messages"):
+		{
+			if err := bot.MessageRepo.Reload(); err != nil {
+				returnMessage = fmt.Sprintf("Keeping the current messages, the message file has problems:\n```%s```", err)
+			} else {
+				returnMessage = "Reloaded messages!"
+			}
+		}
+	case strings.Contains(message.Text, "post digest"):
+		{
+			bot.background(bot.PostLateDigest)
+			returnMessage = "Posting the late digest!"
 		}
 	default:
 		{
 			returnMessage = fmt.Sprintf(
-				"Commands:\n Message tardy users `<@%s>: slap users!`\n Remind users nicely `<@%s>: remind users {{Text of message here}}`\nBother tardy users `<@%s>: bother users!`\nFind out who is late `<@%s>: who is late?`",
+				"Commands:\n Message tardy users `<@%s>: slap users!`\n Remind users nicely `<@%s>: remind users {{Text of message here}}`\nBother tardy users `<@%s>: bother users for 2h in #general max 3 times`\nSee who is being bothered `<@%s>: bother status`\nStop bothering users `<@%s>: stop bothering`\nFind out who is late `<@%s>: who is late?`\nPost the late digest `<@%s>: post digest`\nScope slapping or the late list to a tock unit `<@%s>: who is late in Engineering?`\nReload the message file `<@%s>: reload messages`\nSet the tone for this channel `<@%s>: channel tone professional`\nSet how late users are nudged in this channel `<@%s>: nudge style reaction`",
+				botID,
+				botID,
+				botID,
+				botID,
+				botID,
+				botID,
+				botID,
 				botID,
 				botID,
 				botID,
@@ -123,9 +236,7 @@ func (bot *Bot) masterMessages(message *slack.MessageEvent) {
 			)
 		}
 	}
-	bot.Slack.SendMessage(bot.Slack.NewOutgoingMessage(
-		returnMessage, message.Channel,
-	))
+	bot.reply(message, returnMessage)
 }
 
 // niceMessage are commands for user who are not late
@@ -134,23 +245,113 @@ func (bot *Bot) niceMessage(message *slack.MessageEvent, user string) {
 	switch {
 	case strings.Contains(message.Text, "hello"):
 		{
-			bot.Slack.SendMessage(bot.Slack.NewOutgoingMessage(
-				bot.MessageRepo.Nice.GenerateMessage(user),
-				message.Channel,
-			))
+			tone := bot.toneFor(user, message.Channel)
+			bot.reply(message, tone.Nice.GenerateMessage(bot.messageData(user), tone.Tags...))
+		}
+	case toneCommand.MatchString(message.Text):
+		{
+			bot.reply(message, bot.setUserTone(message.Text, user))
+		}
+	case languageCommand.MatchString(message.Text):
+		{
+			bot.reply(message, bot.setLanguage(message.Text, user))
+		}
+	case strings.Contains(message.Text, "streak"):
+		{
+			bot.background(func() {
+				bot.reply(message, bot.streakMessage(user))
+			})
+		}
+	case strings.Contains(message.Text, "leaderboard"):
+		{
+			bot.background(func() {
+				bot.reply(message, bot.leaderboardMessage())
+			})
 		}
 	case strings.Contains(message.Text, "status"):
 		{
-			go func() {
-				if bot.isLateUser(user) {
-					returnMessage = fmt.Sprintf("<@%s>, you're late -_-", user)
-				} else {
-					returnMessage = fmt.Sprintf("<@%s>, you're on time! ^_^", user)
-				}
-				bot.Slack.SendMessage(bot.Slack.NewOutgoingMessage(
-					returnMessage, message.Channel,
-				))
-			}()
+			bot.background(func() {
+				returnMessage = bot.statusMessage(user)
+				bot.reply(message, returnMessage)
+			})
+		}
+	}
+}
+
+// setLanguage stores the language a user asked for with `language es` or a
+// slack locale such as `es-MX`. `language auto` goes back to the slack
+// locale.
+func (bot *Bot) setLanguage(text string, user string) string {
+	languageFinder := regexp.MustCompile(`\blanguage\s+([A-Za-z_-]+)`)
+	found := languageFinder.FindStringSubmatch(text)
+	locales := bot.MessageRepo.Locales()
+	sort.Strings(locales)
+	available := strings.Join(append([]string{"en", "auto"}, locales...), ", ")
+	if found == nil {
+		return fmt.Sprintf("<@%s>, choose a language with `language es`. Available: %s", user, available)
+	}
+	// Slack writes locales such as `en-US`, which fall back to the language
+	locale := strings.ToLower(strings.Replace(found[1], "_", "-", -1))
+	language := strings.SplitN(locale, "-", 2)[0]
+	switch {
+	case locale == "auto":
+		bot.localePreferences.Delete(user)
+		return fmt.Sprintf("<@%s>, I'll use your slack language.", user)
+	case language == "en":
+		locale = "en"
+	case bot.MessageRepo.Localized(locale) == bot.MessageRepo.Current():
+		return fmt.Sprintf("<@%s>, I don't speak `%s` yet. Available: %s", user, locale, available)
+	}
+	bot.localePreferences.Update(user, locale)
+	return fmt.Sprintf("<@%s>, I'll talk to you in `%s` from now on.", user, locale)
+}
+
+// findTone returns the tone named after `tone` in a message, `default` to
+// clear it, or an error message listing the tones
+func (bot *Bot) findTone(text string) (string, string) {
+	toneFinder := regexp.MustCompile(`\btone\s+([A-Za-z_-]+)`)
+	found := toneFinder.FindStringSubmatch(text)
+	tones := bot.MessageRepo.Current().ToneNames()
+	available := strings.Join(append(tones, "default"), ", ")
+	if found == nil {
+		return "", fmt.Sprintf("Choose a tone with `tone professional`. Available: %s", available)
+	}
+	tone := strings.ToLower(found[1])
+	if tone == "default" {
+		return tone, ""
+	}
+	for _, name := range tones {
+		if name == tone {
+			return tone, ""
 		}
 	}
+	return "", fmt.Sprintf("I don't know the `%s` tone. Available: %s", tone, available)
+}
+
+// setUserTone stores the tone a user asked for with `tone professional`
+func (bot *Bot) setUserTone(text string, user string) string {
+	tone, errMessage := bot.findTone(text)
+	switch {
+	case errMessage != "":
+		return fmt.Sprintf("<@%s>, %s", user, errMessage)
+	case tone == "default":
+		bot.userTones.Delete(user)
+		return fmt.Sprintf("<@%s>, I'll use the channel's tone with you.", user)
+	}
+	bot.userTones.Update(user, tone)
+	return fmt.Sprintf("<@%s>, I'll be %s with you from now on.", user, tone)
+}
+
+// setChannelTone stores the tone an admin chose for a channel
+func (bot *Bot) setChannelTone(text string, channel string) string {
+	tone, errMessage := bot.findTone(text)
+	switch {
+	case errMessage != "":
+		return errMessage
+	case tone == "default":
+		bot.channelTones.Delete(channel)
+		return fmt.Sprintf("This channel is back to the default tone (%s).", bot.defaultTone)
+	}
+	bot.channelTones.Update(channel, tone)
+	return fmt.Sprintf("I'll be %s in this channel from now on.", tone)
 }
@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+)
+
+// Check that languages and slack locales are stored and unknown languages
+// are refused
+func TestSetLanguage(t *testing.T) {
+	bot := newTestBot(t, nil)
+	tests := []struct {
+		Text     string
+		Reply    string
+		Language string
+	}{
+		{"<@UBOT> language es", "<@U1>, I'll talk to you in `es` from now on.", "es"},
+		{"<@UBOT> language en-US", "<@U1>, I'll talk to you in `en` from now on.", "en"},
+		{"<@UBOT> language es_MX please", "<@U1>, I'll talk to you in `es-mx` from now on.", "es-mx"},
+		{"<@UBOT> language fr", "<@U1>, I don't speak `fr` yet.", "es-mx"},
+		{"<@UBOT> language auto", "<@U1>, I'll use your slack language.", ""},
+		{"<@UBOT> language", "<@U1>, choose a language with `language es`.", ""},
+	}
+	for _, test := range tests {
+		if reply := bot.setLanguage(test.Text, "U1"); !strings.HasPrefix(reply, test.Reply) {
+			t.Errorf("%s: %s", test.Text, reply)
+		}
+		if language := bot.localePreferences.Get("U1"); language != test.Language {
+			t.Errorf("%s: %s", test.Text, language)
+		}
+	}
+	if bot.messagesFor("U1") != bot.MessageRepo.Current() {
+		t.Error("users without a language should get the default messages")
+	}
+	bot.localePreferences.Update("U1", "es-mx")
+	if bot.messagesFor("U1") != bot.MessageRepo.Localized("es") {
+		t.Error("es-mx should use the spanish messages")
+	}
+}
+
+// Check that the tone and language commands are only found as words
+func TestToneAndLanguageCommands(t *testing.T) {
+	tests := []struct {
+		Text     string
+		Tone     bool
+		Language bool
+	}{
+		{"<@UBOT> tone professional", true, false},
+		{"<@UBOT> channel tone playful", true, false},
+		{"<@UBOT> language es", false, true},
+		{"<@UBOT> status of the milestone", false, false},
+		{"<@UBOT> stone cold", false, false},
+		{"<@UBOT> what's my status? I want to atone", false, false},
+		{"<@UBOT> languages", false, false},
+	}
+	for _, test := range tests {
+		if toneCommand.MatchString(test.Text) != test.Tone || languageCommand.MatchString(test.Text) != test.Language {
+			t.Error(test.Text)
+		}
+	}
+}
+
+// Check that users keep their tone in a translation without tones
+func TestToneForTranslation(t *testing.T) {
+	bot := newTestBot(t, nil)
+	bot.localePreferences.Update("U1", "es")
+	bot.userTones.Update("U1", "professional")
+	spanish := bot.MessageRepo.Localized("es")
+	tone := bot.toneFor("U1", "")
+	if tone.Angry != spanish.Category("ProfessionalMessages") || tone.Nice != spanish.Nice {
+		t.Error(tone)
+	}
+	bot.userTones.Delete("U1")
+	if tone := bot.toneFor("U1", ""); tone.Angry != spanish.Angry {
+		t.Error(tone)
+	}
+}
+
+// Check that personal reminder commands must follow the bot's mention
+func TestMentionedCommand(t *testing.T) {
+	tests := []struct {
+		Text   string
+		Output bool
+	}{
+		{"<@UBOT> remind me at 4pm", true},
+		{"<@UBOT>: remind me every friday at 4pm", true},
+		{"<@UBOT> remind users {{please fill out tock, remind me if you can't}}", false},
+		{"<@UBOT> remind meeting", false},
+		{"<@UOTHER> remind me at 4pm", false},
+	}
+	for _, test := range tests {
+		if found := mentionedCommand(test.Text, "UBOT", "remind me"); found != test.Output {
+			t.Errorf("%s: %t", test.Text, found)
+		}
+	}
+}
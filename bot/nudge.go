@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+// nudgeStyles are the ways the bot can nudge a late user it is bothering
+var nudgeStyles = []string{"message", "reaction", "thread", "ephemeral", "dm"}
+
+// nudgeEmoji is the reaction added in the reaction style
+const nudgeEmoji = "alarm_clock"
+
+// isNudgeStyle checks if a name is one of the nudge styles
+func isNudgeStyle(style string) bool {
+	for _, name := range nudgeStyles {
+		if name == style {
+			return true
+		}
+	}
+	return false
+}
+
+// nudgeStyleFor returns the nudge style for a channel
+func (bot *Bot) nudgeStyleFor(channel string) string {
+	if style := bot.channelNudgeStyles.Get(channel); style != "" {
+		return style
+	}
+	return bot.defaultNudgeStyle
+}
+
+// nudge sends a message to a late user the way the channel's nudge style
+// asks for. The reaction style only reacts to the user's message.
+func (bot *Bot) nudge(message *slack.MessageEvent, user string, text string) {
+	switch bot.nudgeStyleFor(message.Channel) {
+	case "reaction":
+		bot.Slack.ReactToMessage(message.Channel, message.Timestamp, nudgeEmoji)
+	case "thread":
+		threadTimestamp := message.ThreadTimestamp
+		if threadTimestamp == "" {
+			threadTimestamp = message.Timestamp
+		}
+		bot.Slack.ReplyInThread(message.Channel, threadTimestamp, text)
+	case "ephemeral":
+		bot.Slack.MessageUserEphemeral(message.Channel, user, text)
+	case "dm":
+		bot.Slack.MessageUser(user, text)
+	default:
+		bot.reply(message, text)
+	}
+}
+
+// setNudgeStyle stores the nudge style an admin chose for a channel with
+// `nudge style reaction`. `nudge style default` goes back to the default.
+func (bot *Bot) setNudgeStyle(text string, channel string) string {
+	styleFinder := regexp.MustCompile(`nudge style\s+([A-Za-z]+)`)
+	found := styleFinder.FindStringSubmatch(text)
+	available := strings.Join(append(nudgeStyles, "default"), ", ")
+	if found == nil {
+		return fmt.Sprintf("Choose a nudge style with `nudge style reaction`. Available: %s", available)
+	}
+	style := strings.ToLower(found[1])
+	switch {
+	case style == "default":
+		bot.channelNudgeStyles.Delete(channel)
+		return fmt.Sprintf("This channel is back to the default nudge style (%s).", bot.defaultNudgeStyle)
+	case !isNudgeStyle(style):
+		return fmt.Sprintf("I don't know the `%s` nudge style. Available: %s", style, available)
+	}
+	bot.channelNudgeStyles.Update(channel, style)
+	return fmt.Sprintf("I'll nudge late users in this channel with the %s style from now on.", style)
+}
@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+// Check choosing, resetting and rejecting nudge styles for a channel
+func TestSetNudgeStyle(t *testing.T) {
+	bot := newTestBot(t, nil)
+	tests := []struct {
+		Text   string
+		Reply  string
+		Output string
+	}{
+		{"<@UBOT> nudge style reaction", "I'll nudge late users in this channel with the reaction style", "reaction"},
+		{"<@UBOT> nudge style Thread", "I'll nudge late users in this channel with the thread style", "thread"},
+		{"<@UBOT> nudge style shout", "I don't know the `shout` nudge style. Available: message, reaction, thread, ephemeral, dm, default", "thread"},
+		{"<@UBOT> nudge style", "Choose a nudge style with `nudge style reaction`.", "thread"},
+		{"<@UBOT> nudge style default", "This channel is back to the default nudge style (message).", "message"},
+	}
+	for _, test := range tests {
+		if reply := bot.setNudgeStyle(test.Text, "C1"); !strings.HasPrefix(reply, test.Reply) {
+			t.Errorf("%s: %s", test.Text, reply)
+		}
+		if style := bot.nudgeStyleFor("C1"); style != test.Output {
+			t.Errorf("%s: %s", test.Text, style)
+		}
+	}
+	if style := bot.nudgeStyleFor("C2"); style != "message" {
+		t.Error("other channels should keep the default style", style)
+	}
+}
+
+// Check that each nudge style sends the nudge its own way
+func TestNudge(t *testing.T) {
+	tests := []struct {
+		Style           string
+		ThreadTimestamp string
+		Output          string
+	}{
+		{"message", "", "channel C1: hurry"},
+		{"message", "100.0", "thread C1 100.0: hurry"},
+		{"reaction", "", "react C1 123.4: alarm_clock"},
+		{"thread", "", "thread C1 123.4: hurry"},
+		{"thread", "100.0", "thread C1 100.0: hurry"},
+		{"ephemeral", "", "ephemeral C1 U1: hurry"},
+		{"dm", "", "dm U1: hurry"},
+	}
+	for _, test := range tests {
+		bot := newTestBot(t, nil)
+		api := &fakeSlack{}
+		bot.Slack = api
+		bot.channelNudgeStyles.Update("C1", test.Style)
+		message := &slack.MessageEvent{Msg: slack.Msg{
+			Channel: "C1", User: "U1", Timestamp: "123.4", ThreadTimestamp: test.ThreadTimestamp,
+		}}
+		bot.nudge(message, "U1", "hurry")
+		if !reflect.DeepEqual(api.sent, []string{test.Output}) {
+			t.Errorf("%s: %q", test.Style, api.sent)
+		}
+	}
+}
+
+// Check how bothered users are nudged while late, once they filled out tock
+// and while tock is down
+func TestViolatorMessage(t *testing.T) {
+	late := map[string]string{
+		"/api/reporting_period_audit.json": testPeriods,
+		testAuditPath("2024-01-15"):        `[{"username":"ada","email":"ada@example.gov"}]`,
+	}
+	onTime := map[string]string{
+		"/api/reporting_period_audit.json": testPeriods,
+		testAuditPath("2024-01-15"):        `[]`,
+	}
+	tests := []struct {
+		Responses map[string]string
+		Style     string
+		Output    string
+		Bothered  bool
+	}{
+		{late, "message", "channel C1: ", true},
+		{late, "reaction", "react C1 123.4: alarm_clock", true},
+		{late, "dm", "dm U1: ", true},
+		{onTime, "message", "channel C1: <@U1>, I was about to yell at you", false},
+		{onTime, "ephemeral", "ephemeral C1 U1: <@U1>, I was about to yell at you", false},
+		// Reactions are only for late users
+		{onTime, "reaction", "", false},
+		// Users stay on the watchlist until tock can say they filled it out
+		{map[string]string{}, "message", "", true},
+	}
+	for _, test := range tests {
+		bot := newTestBot(t, test.Responses)
+		api := &fakeSlack{}
+		bot.Slack = api
+		bot.UserEmailMap.Update("ada@example.gov", "U1")
+		bot.channelNudgeStyles.Update("C1", test.Style)
+		entry := botherEntry{Email: "ada@example.gov", Remaining: 3}
+		bot.violatorUserMap.SetWithTTL("U1", entry, time.Hour)
+		message := &slack.MessageEvent{Msg: slack.Msg{Channel: "C1", User: "U1", Timestamp: "123.4", Text: "hi"}}
+
+		bot.violatorMessage(message, "U1", entry)
+		if test.Output == "" && len(api.sent) != 0 {
+			t.Errorf("%s: %q", test.Style, api.sent)
+		}
+		if test.Output != "" && (len(api.sent) != 1 || !strings.HasPrefix(api.sent[0], test.Output)) {
+			t.Errorf("%s: %q", test.Style, api.sent)
+		}
+		// Messages mention the user, reactions are on their message
+		if len(api.sent) == 1 && test.Style != "reaction" && !strings.Contains(api.sent[0], "<@U1>") {
+			t.Errorf("%s: %q", test.Style, api.sent)
+		}
+		if _, bothered := bot.violatorUserMap.GetOK("U1"); bothered != test.Bothered {
+			t.Errorf("%s: bothered %t", test.Style, bothered)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// reminderLogSize is how many reminder runs are kept
+const reminderLogSize = 20
+
+// reminderRun is one time late users were reminded
+type reminderRun struct {
+	At     time.Time
+	Kind   string
+	Period string
+	Count  int
+}
+
+// reminderLog keeps the most recent reminder runs in memory
+type reminderLog struct {
+	mutex sync.Mutex
+	runs  []reminderRun
+}
+
+// recordReminderRun adds a reminder run to the log, dropping the oldest run
+// when the log is full
+func (bot *Bot) recordReminderRun(kind string, period string, count int) {
+	bot.reminderLog.mutex.Lock()
+	defer bot.reminderLog.mutex.Unlock()
+	run := reminderRun{At: time.Now(), Kind: kind, Period: period, Count: count}
+	bot.reminderLog.runs = append(bot.reminderLog.runs, run)
+	if len(bot.reminderLog.runs) > reminderLogSize {
+		bot.reminderLog.runs = bot.reminderLog.runs[1:]
+	}
+}
+
+// recentReminderRuns returns the logged reminder runs, newest first
+func (bot *Bot) recentReminderRuns() []reminderRun {
+	bot.reminderLog.mutex.Lock()
+	defer bot.reminderLog.mutex.Unlock()
+	runs := make([]reminderRun, len(bot.reminderLog.runs))
+	for idx, run := range bot.reminderLog.runs {
+		runs[len(runs)-1-idx] = run
+	}
+	return runs
+}
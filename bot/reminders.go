@@ -0,0 +1,233 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// personalReminder is a time a user asked to be reminded about tock
+type personalReminder struct {
+	// Every is `day` or a weekday for reminders that repeat and empty for
+	// reminders that happen once
+	Every  string `json:"every,omitempty"`
+	Hour   int    `json:"hour"`
+	Minute int    `json:"minute"`
+	// Timezone is the user's slack time zone when the reminder was made
+	Timezone string    `json:"timezone"`
+	Next     time.Time `json:"next"`
+}
+
+// weekdays maps the names of the days to weekdays
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// parseClock reads a time of day such as `4pm`, `9:30am` or `16:30` after
+// `at` in a message
+func parseClock(text string) (int, int, bool) {
+	clockFinder := regexp.MustCompile(`\bat\s+(\d{1,2})(?::(\d{2}))?\s*(am|pm)?`)
+	found := clockFinder.FindStringSubmatch(text)
+	if found == nil {
+		return 0, 0, false
+	}
+	hour, _ := strconv.Atoi(found[1])
+	minute := 0
+	if found[2] != "" {
+		minute, _ = strconv.Atoi(found[2])
+	}
+	if found[3] != "" && (hour == 0 || hour > 12) {
+		return 0, 0, false
+	}
+	switch found[3] {
+	case "am":
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+// location returns the time zone of the reminder
+func (reminder personalReminder) location() *time.Location {
+	location, err := time.LoadLocation(reminder.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
+
+// nextAfter returns the first time after now that the reminder happens on
+// the day it is set for, or any day for one time reminders
+func (reminder personalReminder) nextAfter(now time.Time) time.Time {
+	location := reminder.location()
+	local := now.In(location)
+	weekday, onWeekday := weekdays[reminder.Every]
+	for day := 0; ; day++ {
+		// Each day's time is made from its date, so a time skipped by a
+		// daylight saving change only moves on that day
+		next := time.Date(local.Year(), local.Month(), local.Day()+day, reminder.Hour, reminder.Minute, 0, 0, location)
+		if skipped := reminder.Hour*60 + reminder.Minute - next.Hour()*60 - next.Minute(); skipped > 0 {
+			// time.Date may pick the offset before the change, so move the
+			// time past the skipped wall clock time
+			next = next.Add(time.Duration(skipped) * time.Minute)
+		}
+		if next.After(now) && (!onWeekday || next.Weekday() == weekday) {
+			return next
+		}
+	}
+}
+
+// describe writes when a reminder happens, e.g. `every friday at 4:00PM`
+func (reminder personalReminder) describe() string {
+	next := reminder.Next.In(reminder.location())
+	if reminder.Every != "" {
+		return fmt.Sprintf("every %s at %s", reminder.Every, next.Format("3:04PM MST"))
+	}
+	return next.Format("Mon Jan 2 at 3:04PM MST")
+}
+
+// errNoReminderTime is returned by parseReminder for messages without a
+// time of day
+var errNoReminderTime = errors.New("no time of day in the reminder")
+
+// parseReminder reads a reminder from a message such as `remind me every
+// friday at 4pm`, `remind me tomorrow at 9` or `remind me at 4pm`
+func parseReminder(text string, now time.Time, location *time.Location) (personalReminder, error) {
+	hour, minute, ok := parseClock(text)
+	if !ok {
+		return personalReminder{}, errNoReminderTime
+	}
+	reminder := personalReminder{Hour: hour, Minute: minute, Timezone: location.String()}
+	everyFinder := regexp.MustCompile(`every\s+(day|sunday|monday|tuesday|wednesday|thursday|friday|saturday)\b`)
+	dayFinder := regexp.MustCompile(`\b(sunday|monday|tuesday|wednesday|thursday|friday|saturday)\b`)
+	if found := everyFinder.FindStringSubmatch(text); found != nil {
+		reminder.Every = found[1]
+		reminder.Next = reminder.nextAfter(now)
+		return reminder, nil
+	}
+	switch found := dayFinder.FindStringSubmatch(text); {
+	case strings.Contains(text, "tomorrow"):
+		// Start looking at the beginning of tomorrow
+		local := now.In(location)
+		tomorrow := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, location)
+		reminder.Next = reminder.nextAfter(tomorrow.Add(-time.Nanosecond))
+	case found != nil:
+		// Happens once on the next of that weekday
+		weekly := reminder
+		weekly.Every = found[1]
+		reminder.Next = weekly.nextAfter(now)
+	default:
+		reminder.Next = reminder.nextAfter(now)
+	}
+	return reminder, nil
+}
+
+// scheduleReminder adds a reminder for a user from a message. Times are in
+// the user's slack time zone.
+func (bot *Bot) scheduleReminder(text string, user string, now time.Time) string {
+	reminder, err := parseReminder(strings.ToLower(text), now, bot.userLocation(user))
+	if err != nil {
+		return "Tell me when, e.g. `remind me at 4pm`, `remind me tomorrow at 9` or `remind me every friday at 4pm`."
+	}
+	bot.personalReminders.Compute(user, func(reminders []personalReminder, ok bool) ([]personalReminder, bool) {
+		// Copy the reminders so readers of the old list aren't affected
+		return append(append([]personalReminder{}, reminders...), reminder), true
+	})
+	return fmt.Sprintf(
+		"Okay, I'll check Tock %s and remind you if your timesheet is missing.",
+		reminder.describe(),
+	)
+}
+
+// listReminders describes the reminders a user has set
+func (bot *Bot) listReminders(user string) string {
+	reminders := bot.personalReminders.Get(user)
+	if len(reminders) == 0 {
+		return "You don't have any reminders. Set one with `remind me every friday at 4pm`."
+	}
+	lines := []string{"Your reminders:"}
+	for _, reminder := range reminders {
+		lines = append(lines, "• "+reminder.describe())
+	}
+	return strings.Join(append(lines, "Remove them with `cancel reminders`."), "\n")
+}
+
+// cancelReminders removes all of a user's reminders
+func (bot *Bot) cancelReminders(user string) string {
+	bot.personalReminders.Delete(user)
+	return "Okay, I removed your reminders."
+}
+
+// advanceReminders returns the reminders left after the due ones happen and
+// if any were due. Repeating reminders are moved to their next time and the
+// others are removed.
+func advanceReminders(reminders []personalReminder, now time.Time) ([]personalReminder, bool) {
+	var remaining []personalReminder
+	due := false
+	for _, reminder := range reminders {
+		if reminder.Next.After(now) {
+			remaining = append(remaining, reminder)
+			continue
+		}
+		due = true
+		if reminder.Every != "" {
+			reminder.Next = reminder.nextAfter(now)
+			remaining = append(remaining, reminder)
+		}
+	}
+	return remaining, due
+}
+
+// SendPersonalReminders messages the users whose reminders are due if their
+// timesheet is still missing. Tock is asked who is late once per run and if
+// it can't answer the reminders stay due until the next run. Each user's
+// reminders are advanced from their current list, so reminders set or
+// cancelled while tock is asked are kept that way.
+func (bot *Bot) SendPersonalReminders() {
+	now := time.Now()
+	var dueUsers []string
+	for user, reminders := range bot.personalReminders.Snapshot() {
+		if _, due := advanceReminders(reminders, now); due {
+			dueUsers = append(dueUsers, user)
+		}
+	}
+	if len(dueUsers) == 0 {
+		return
+	}
+	late, err := bot.lateSlackUsers()
+	if err != nil {
+		log.Printf("Unable to check who is late for personal reminders: %s", err)
+		return
+	}
+	for _, user := range dueUsers {
+		due := false
+		bot.personalReminders.Compute(user, func(reminders []personalReminder, ok bool) ([]personalReminder, bool) {
+			var remaining []personalReminder
+			remaining, due = advanceReminders(reminders, now)
+			return remaining, len(remaining) > 0
+		})
+		if !due || bot.isPaused(user) || !late[user] {
+			continue
+		}
+		tone := bot.toneFor(user, "")
+		bot.Slack.MessageUser(user, tone.Reminder.GenerateMessage(bot.messageData(user), tone.Tags...))
+	}
+}
@@ -0,0 +1,182 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/18F/angrytock/helpers"
+	// Load time zones the same way on every machine
+	_ "time/tzdata"
+)
+
+// Check reminders that repeat, happen tomorrow, on a weekday or at the next
+// time, around the start of daylight saving time on 2024-03-10
+func TestParseReminder(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Friday at 5pm
+	now := time.Date(2024, 3, 8, 17, 0, 0, 0, newYork)
+	at := func(month time.Month, day int, hour int, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, newYork)
+	}
+	tests := []struct {
+		Text  string
+		Every string
+		Next  time.Time
+	}{
+		{"remind me at 6pm", "", at(3, 8, 18, 0)},
+		// Times that passed today are tomorrow
+		{"remind me at 4pm", "", at(3, 9, 16, 0)},
+		{"remind me at 5pm", "", at(3, 9, 17, 0)},
+		{"remind me tomorrow at 9", "", at(3, 9, 9, 0)},
+		{"remind me tomorrow at 12am", "", at(3, 9, 0, 0)},
+		{"remind me monday at 10am", "", at(3, 11, 10, 0)},
+		{"remind me friday at 4pm", "", at(3, 15, 16, 0)},
+		{"remind me every friday at 6pm", "friday", at(3, 8, 18, 0)},
+		// Next friday is after the change to daylight saving time
+		{"remind me every friday at 4pm", "friday", at(3, 15, 16, 0)},
+		{"remind me every day at 9:30am", "day", at(3, 9, 9, 30)},
+	}
+	for _, test := range tests {
+		reminder, err := parseReminder(test.Text, now, newYork)
+		if err != nil {
+			t.Errorf("%s: %s", test.Text, err)
+			continue
+		}
+		if reminder.Every != test.Every || !reminder.Next.Equal(test.Next) {
+			t.Errorf("%s: every %q at %s", test.Text, reminder.Every, reminder.Next)
+		}
+		if reminder.Timezone != "America/New_York" {
+			t.Error(reminder.Timezone)
+		}
+	}
+	if _, err := parseReminder("remind me on friday", now, newYork); err != errNoReminderTime {
+		t.Error(err)
+	}
+}
+
+// Check that daily reminders keep their time of day across daylight saving
+// changes and that a time skipped by the change moves forward on that day only
+func TestReminderNextAfter(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(month time.Month, day int, hour int, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, newYork)
+	}
+	daily := personalReminder{Every: "day", Hour: 16, Minute: 0, Timezone: "America/New_York"}
+	earlyDaily := personalReminder{Every: "day", Hour: 2, Minute: 30, Timezone: "America/New_York"}
+	weekly := personalReminder{Every: "sunday", Hour: 2, Minute: 30, Timezone: "America/New_York"}
+	tests := []struct {
+		Reminder personalReminder
+		Now      time.Time
+		Next     time.Time
+	}{
+		{daily, at(3, 9, 16, 0), at(3, 10, 16, 0)},
+		{daily, at(3, 10, 16, 0), at(3, 11, 16, 0)},
+		{daily, at(11, 2, 16, 0), at(11, 3, 16, 0)},
+		// 2:30am doesn't exist on 2024-03-10
+		{earlyDaily, at(3, 9, 12, 0), at(3, 10, 3, 30)},
+		{earlyDaily, at(3, 10, 4, 0), at(3, 11, 2, 30)},
+		{weekly, at(3, 9, 12, 0), at(3, 10, 3, 30)},
+		{weekly, at(3, 10, 4, 0), at(3, 17, 2, 30)},
+		// Reminders use their own time zone, not the time zone of now
+		{daily, time.Date(2024, 3, 8, 20, 0, 0, 0, time.UTC), at(3, 8, 16, 0)},
+	}
+	for _, test := range tests {
+		if next := test.Reminder.nextAfter(test.Now); !next.Equal(test.Next) {
+			t.Errorf("%+v after %s: %s", test.Reminder, test.Now, next)
+		}
+	}
+}
+
+// Check that due reminders are kept while tock can't say who is late and
+// removed or moved once it can
+func TestSendPersonalReminders(t *testing.T) {
+	responses := map[string]string{}
+	bot := newTestBot(t, responses)
+	bot.UserEmailMap.Update("ada@example.gov", "U1")
+	due := time.Now().Add(-time.Minute)
+	later := time.Now().Add(time.Hour)
+	bot.personalReminders.Update("U1", []personalReminder{
+		{Hour: 9, Timezone: "UTC", Next: due},
+		{Every: "day", Hour: 9, Timezone: "UTC", Next: due},
+		{Hour: 9, Timezone: "UTC", Next: later},
+	})
+
+	bot.SendPersonalReminders()
+	if reminders := bot.personalReminders.Get("U1"); len(reminders) != 3 || !reminders[0].Next.Equal(due) {
+		t.Error("reminders should stay due while tock is down", reminders)
+	}
+
+	// Tock is back and U1 filled out their timesheet, so nothing is sent
+	responses["/api/reporting_period_audit.json"] = testPeriods
+	responses[testAuditPath("2024-01-15")] = `[]`
+	bot.SendPersonalReminders()
+	reminders := bot.personalReminders.Get("U1")
+	if len(reminders) != 2 || reminders[0].Every != "day" || !reminders[0].Next.After(time.Now()) {
+		t.Error(reminders)
+	}
+	if !reminders[1].Next.Equal(later) {
+		t.Error(reminders[1])
+	}
+}
+
+// Check that reminders set or cancelled while tock is asked who is late
+// aren't lost or brought back
+func TestSendPersonalRemindersWhileFetching(t *testing.T) {
+	responses := map[string]string{
+		"/api/reporting_period_audit.json": testPeriods,
+		testAuditPath("2024-01-15"): `[
+			{"username":"ada","email":"ada@example.gov"},
+			{"username":"grace","email":"grace@example.gov"}
+		]`,
+	}
+	bot := newTestBot(t, responses)
+	api := &fakeSlack{}
+	bot.Slack = api
+	bot.UserEmailMap.Update("ada@example.gov", "U1")
+	bot.UserEmailMap.Update("grace@example.gov", "U2")
+	due := []personalReminder{{Hour: 9, Timezone: "UTC", Next: time.Now().Add(-time.Minute)}}
+	bot.personalReminders.Update("U1", due)
+	bot.personalReminders.Update("U2", due)
+	fetcher := bot.Tock.DataFetcher.GenericDataFetcherHolder
+	bot.Tock.DataFetcher = helpers.NewDataFetcher(func(URL string) []byte {
+		if strings.HasSuffix(URL, testAuditPath("2024-01-15")) {
+			bot.scheduleReminder("remind me every friday at 4pm", "U1", time.Now())
+			bot.cancelReminders("U2")
+		}
+		return fetcher(URL)
+	})
+
+	bot.SendPersonalReminders()
+	if reminders := bot.personalReminders.Get("U1"); len(reminders) != 1 || reminders[0].Every != "friday" {
+		t.Error("the new reminder should be kept", reminders)
+	}
+	if reminders, ok := bot.personalReminders.GetOK("U2"); ok {
+		t.Error("cancelled reminders should stay cancelled", reminders)
+	}
+	if len(api.sent) != 1 || !strings.HasPrefix(api.sent[0], "dm U1: ") {
+		t.Error(api.sent)
+	}
+}
+
+// Check the advancing of due reminders
+func TestAdvanceReminders(t *testing.T) {
+	now := time.Now()
+	if remaining, due := advanceReminders(nil, now); due || remaining != nil {
+		t.Error(remaining, due)
+	}
+	upcoming := []personalReminder{{Hour: 9, Timezone: "UTC", Next: now.Add(time.Minute)}}
+	if remaining, due := advanceReminders(upcoming, now); due || len(remaining) != 1 {
+		t.Error(remaining, due)
+	}
+	once := []personalReminder{{Hour: 9, Timezone: "UTC", Next: now}}
+	if remaining, due := advanceReminders(once, now); !due || len(remaining) != 0 {
+		t.Error(remaining, due)
+	}
+}
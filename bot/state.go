@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// stateDict is a dictionary that can be saved in the state directory
+type stateDict interface {
+	LoadFile(path string) error
+	SaveFile(path string) error
+	StartSnapshots(path string, interval time.Duration)
+	Len() int
+	Close()
+}
+
+// stateFiles maps the file names in the state directory to the
+// dictionaries saved in them
+func (bot *Bot) stateFiles() map[string]stateDict {
+	return map[string]stateDict{
+		"user_emails.json":          bot.UserEmailMap,
+		"violators.json":            bot.violatorUserMap,
+		"supervisors_notified.json": bot.supervisorNotified,
+		"reminded_users.json":       bot.remindedUsers,
+		"user_locales.json":         bot.userLocales,
+		"locale_preferences.json":   bot.localePreferences,
+		"channel_tones.json":        bot.channelTones,
+		"user_tones.json":           bot.userTones,
+		"nudge_styles.json":         bot.channelNudgeStyles,
+		"paused_users.json":         bot.pausedUsers,
+		"user_timezones.json":       bot.userTimezones,
+		"personal_reminders.json":   bot.personalReminders,
+	}
+}
+
+// restoreState loads the dictionaries saved in the state directory. Missing
+// files are skipped so the bot starts empty the first time.
+func (bot *Bot) restoreState() {
+	if bot.stateDir == "" {
+		return
+	}
+	for name, dict := range bot.stateFiles() {
+		err := dict.LoadFile(filepath.Join(bot.stateDir, name))
+		if err != nil && !os.IsNotExist(err) {
+			log.Printf("Unable to restore %s: %s", name, err)
+		}
+	}
+	// The master list holds emails until slack ids are known
+	for email, userID := range bot.UserEmailMap.Snapshot() {
+		bot.updateMasterList(email, userID)
+	}
+	log.Printf("Restored %d slack users from %s", bot.UserEmailMap.Len(), bot.stateDir)
+}
+
+// startSnapshots saves the dictionaries to the state directory every interval
+func (bot *Bot) startSnapshots(interval time.Duration) {
+	if bot.stateDir == "" {
+		return
+	}
+	if err := os.MkdirAll(bot.stateDir, 0o755); err != nil {
+		log.Printf("Unable to create %s: %s", bot.stateDir, err)
+		return
+	}
+	for name, dict := range bot.stateFiles() {
+		dict.StartSnapshots(filepath.Join(bot.stateDir, name), interval)
+	}
+}
+
+// SaveState saves the dictionaries to the state directory right away
+func (bot *Bot) SaveState() {
+	if bot.stateDir == "" {
+		return
+	}
+	for name, dict := range bot.stateFiles() {
+		if err := dict.SaveFile(filepath.Join(bot.stateDir, name)); err != nil {
+			log.Printf("Unable to save %s: %s", name, err)
+		}
+	}
+}
+
+// closeState stops the sweepers and snapshots of the dictionaries
+func (bot *Bot) closeState() {
+	for _, dict := range bot.stateFiles() {
+		dict.Close()
+	}
+}
@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/18F/angrytock/tock"
+)
+
+// statusPeriodCount is the number of ended reporting periods checked for
+// missing timecards
+const statusPeriodCount = 4
+
+// periodRange writes the dates of a reporting period in a user's language
+func (bot *Bot) periodRange(slackUserID string, period tockPackage.ReportingPeriod) string {
+	mrep := bot.messagesFor(slackUserID)
+	return fmt.Sprintf("%s to %s", mrep.FormatDate(period.StartDate), mrep.FormatDate(period.EndDate))
+}
+
+// isLateForPeriod checks if a slack user is late for a reporting period. An
+// error is returned if tock can't list every late user.
+func (bot *Bot) isLateForPeriod(slackUserID string, timePeriod string) (bool, error) {
+	found := false
+	err := bot.Tock.PeriodUserApplier(timePeriod, func(user tockPackage.User) {
+		if bot.UserEmailMap.Get(user.Email) == slackUserID {
+			found = true
+		}
+	})
+	return found, err
+}
+
+// statusMessage describes a user's missing timecards, the latest reporting
+// period that ended and the next deadline
+func (bot *Bot) statusMessage(user string) string {
+	recent, next := bot.Tock.FetchReportingPeriods().Timeline(statusPeriodCount)
+	if len(recent) == 0 {
+		return fmt.Sprintf("<@%s>, I couldn't find any reporting periods in Tock :(", user)
+	}
+
+	var missing []string
+	for _, period := range recent {
+		late, err := bot.isLateForPeriod(user, period.StartDate)
+		if err != nil {
+			return fmt.Sprintf("<@%s>, I couldn't check your timecards in Tock, try again later :(", user)
+		}
+		if late {
+			missing = append(missing, bot.periodRange(user, period))
+		}
+	}
+	latest := recent[0]
+
+	var lines []string
+	if len(missing) > 0 {
+		lines = append(lines,
+			fmt.Sprintf("<@%s>, you're late -_-", user),
+			"Missing timecards: "+strings.Join(missing, ", "),
+		)
+	} else {
+		lines = append(lines, fmt.Sprintf("<@%s>, you're on time! ^_^", user))
+	}
+	lines = append(lines,
+		fmt.Sprintf("Latest period: %s, %s", bot.periodRange(user, latest), latest.HoursRequirement()),
+		fmt.Sprintf("Timecard: %s", bot.Tock.TimecardURL(latest)),
+	)
+	if next != nil {
+		lines = append(lines, fmt.Sprintf("Next deadline: %s", bot.messagesFor(user).FormatDate(next.EndDate)))
+	}
+	return strings.Join(lines, "\n")
+}
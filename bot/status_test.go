@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+)
+
+// Check the status of a late user in english and in a translation
+func TestStatusMessage(t *testing.T) {
+	bot := newTestBot(t, map[string]string{
+		"/api/reporting_period_audit.json": testPeriods,
+		testAuditPath("2024-01-15"):        `[]`,
+		testAuditPath("2024-01-08"):        `[{"username":"ada","email":"ada@example.gov"}]`,
+		testAuditPath("2024-01-01"):        `[]`,
+	})
+	bot.UserEmailMap.Update("ada@example.gov", "U1")
+
+	expected := "<@U1>, you're late -_-\n" +
+		"Missing timecards: 2024-01-08 to 2024-01-14\n" +
+		"Latest period: 2024-01-15 to 2024-01-21, 40 hours required\n" +
+		"Timecard: https://tock.example.gov/reporting_period/2024-01-15/"
+	if message := bot.statusMessage("U1"); message != expected {
+		t.Error(message)
+	}
+
+	bot.localePreferences.Update("U1", "es")
+	expected = "<@U1>, you're late -_-\n" +
+		"Missing timecards: 08/01/2024 to 14/01/2024\n" +
+		"Latest period: 15/01/2024 to 21/01/2024, 40 hours required\n" +
+		"Timecard: https://tock.example.gov/reporting_period/2024-01-15/"
+	if message := bot.statusMessage("U1"); message != expected {
+		t.Error(message)
+	}
+
+	if message := bot.statusMessage("U2"); !strings.HasPrefix(message, "<@U2>, you're on time! ^_^\nLatest period") {
+		t.Error(message)
+	}
+}
+
+// Check that users aren't told they're on time when tock lists the periods
+// but not their late users
+func TestStatusMessageTockDown(t *testing.T) {
+	bot := newTestBot(t, map[string]string{
+		"/api/reporting_period_audit.json": testPeriods,
+		testAuditPath("2024-01-15"):        `[]`,
+	})
+	bot.UserEmailMap.Update("ada@example.gov", "U1")
+	expected := "<@U1>, I couldn't check your timecards in Tock, try again later :("
+	if message := bot.statusMessage("U1"); message != expected {
+		t.Error(message)
+	}
+	if message := newTestBot(t, nil).statusMessage("U1"); !strings.Contains(message, "couldn't find any reporting periods") {
+		t.Error(message)
+	}
+}
@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/18F/angrytock/tock"
+)
+
+// escalationDue checks if the escalation delay after the end of a
+// reporting period has passed
+func escalationDue(period *tockPackage.ReportingPeriod, delay time.Duration, now time.Time) bool {
+	endDate, err := time.Parse("2006-01-02", period.EndDate)
+	if err != nil {
+		log.Print(err)
+		return false
+	}
+	// Periods end at the end of the day
+	return now.After(endDate.Add(24 * time.Hour).Add(delay))
+}
+
+// collectLateReports groups the late users of a period by supervisor email.
+// An error is returned if tock can't list every late user and profile.
+func (bot *Bot) collectLateReports(period *tockPackage.ReportingPeriod) (map[string][]tockPackage.User, error) {
+	reports := make(map[string][]tockPackage.User)
+	err := bot.Tock.ProfiledUserApplier(period.StartDate, func(user tockPackage.User) {
+		if user.Profile != nil && user.Profile.Supervisor != "" {
+			supervisor := user.Profile.Supervisor
+			reports[supervisor] = append(reports[supervisor], user)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// supervisorMessage lists a supervisor's late direct reports with links to tock
+func (bot *Bot) supervisorMessage(period *tockPackage.ReportingPeriod, reports []tockPackage.User) string {
+	lines := []string{fmt.Sprintf(
+		"The following people who report to you have not filled out Tock for %s:",
+		period.DateRange(),
+	)}
+	for _, user := range reports {
+		lines = append(lines, fmt.Sprintf(
+			"• <%s|%s>", bot.Tock.EmployeeURL(user.Username), userDisplayName(user),
+		))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// NotifySupervisors sends each supervisor one message per reporting period
+// listing their direct reports who are still late. Nobody is notified while
+// tock can't list them all, so the full lists go out on a later run.
+func (bot *Bot) NotifySupervisors() {
+	if !bot.supervisorNotifications {
+		return
+	}
+	period, _ := bot.Tock.FetchCurrentAndPreviousPeriods()
+	if period == nil || !escalationDue(period, bot.supervisorEscalationDelay, time.Now()) {
+		return
+	}
+	reports, err := bot.collectLateReports(period)
+	if err != nil {
+		log.Printf("Unable to notify supervisors: %s", err)
+		return
+	}
+	log.Println("Notifying supervisors")
+	for supervisor, reports := range reports {
+		if bot.supervisorNotified.Get(supervisor) == period.StartDate {
+			continue
+		}
+		supervisorID := bot.UserEmailMap.Get(supervisor)
+		if supervisorID == "" {
+			continue
+		}
+		bot.Slack.MessageUser(supervisorID, bot.supervisorMessage(period, reports))
+		bot.supervisorNotified.Update(supervisor, period.StartDate)
+	}
+}
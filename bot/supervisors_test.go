@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/18F/angrytock/tock"
+)
+
+// Check that supervisors are only notified once the delay after the end of
+// the period has passed
+func TestEscalationDue(t *testing.T) {
+	period := &tockPackage.ReportingPeriod{StartDate: "2024-01-01", EndDate: "2024-01-07"}
+	// The period ends at the end of 2024-01-07
+	periodEnd := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		Period *tockPackage.ReportingPeriod
+		Delay  time.Duration
+		Now    time.Time
+		Output bool
+	}{
+		{period, 48 * time.Hour, periodEnd.Add(-time.Hour), false},
+		{period, 48 * time.Hour, periodEnd.Add(47 * time.Hour), false},
+		{period, 48 * time.Hour, periodEnd.Add(48 * time.Hour), false},
+		{period, 48 * time.Hour, periodEnd.Add(49 * time.Hour), true},
+		{period, 0, periodEnd.Add(time.Minute), true},
+		{&tockPackage.ReportingPeriod{EndDate: "not a date"}, 0, periodEnd, false},
+	}
+	for _, test := range tests {
+		if due := escalationDue(test.Period, test.Delay, test.Now); due != test.Output {
+			t.Errorf("%s after %s at %s: %t", test.Delay, test.Period.EndDate, test.Now, due)
+		}
+	}
+}
+
+// Check that supervisors aren't notified, or marked as notified, while tock
+// can't list every late user and profile
+func TestNotifySupervisorsTockDown(t *testing.T) {
+	responses := map[string]string{
+		"/api/reporting_period_audit.json": testPeriods,
+		testAuditPath("2024-01-15"): `[
+			{"username":"ada","email":"ada@example.gov"},
+			{"username":"grace","email":"grace@example.gov"}
+		]`,
+	}
+	bot := newTestBot(t, responses)
+	bot.supervisorNotifications = true
+	// Messaging the mapped supervisor would use the missing slack connection
+	bot.UserEmailMap.Update("boss@example.gov", "U9")
+
+	bot.NotifySupervisors()
+	if notified := bot.supervisorNotified.Get("boss@example.gov"); notified != "" {
+		t.Error("supervisors shouldn't be notified without user data", notified)
+	}
+
+	responses["/api/user_data.json"] = `[
+		{"user":"ada","supervisor":"boss@example.gov"},
+		{"user":"grace","supervisor":"boss@example.gov"},
+		{"user":"linus","supervisor":"other@example.gov"}
+	]`
+	period, _ := bot.Tock.FetchCurrentAndPreviousPeriods()
+	reports, err := bot.collectLateReports(period)
+	if err != nil || len(reports) != 1 || len(reports["boss@example.gov"]) != 2 {
+		t.Error(reports, err)
+	}
+
+	delete(responses, testAuditPath("2024-01-15"))
+	if reports, err := bot.collectLateReports(period); err == nil || reports != nil {
+		t.Error(reports, err)
+	}
+	bot.NotifySupervisors()
+	if notified := bot.supervisorNotified.Get("boss@example.gov"); notified != "" {
+		t.Error("supervisors shouldn't be notified without every late user", notified)
+	}
+}
@@ -5,32 +5,76 @@ Script for starting the bot server
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/18F/angrytock/bot"
-	"github.com/robfig/cron"
+	"github.com/18F/angrytock/messages"
+	"github.com/18F/angrytock/metrics"
 )
 
+// lintMessages validates the embedded messages with the message files merged
+// on top and prints any problems found. It returns the exit code for the
+// command.
+func lintMessages(messageFiles []string) int {
+	_, err := messagesPackage.NewMessageStore(messageFiles)
+	if err == nil {
+		fmt.Println("Messages are valid")
+		return 0
+	}
+	fmt.Println(err)
+	var errs messagesPackage.ValidationErrors
+	if errors.As(err, &errs) {
+		fmt.Printf("%d problems found\n", len(errs))
+	}
+	return 1
+}
+
 func main() {
 
-	bot := bot.InitBot()
+	// `angrytock lint-messages [file or url...]` checks the messages and exits
+	if len(os.Args) > 1 && os.Args[1] == "lint-messages" {
+		os.Exit(lintMessages(os.Args[2:]))
+	}
 
-	bot.StoreSlackUsers()
+	bot := bot.InitBot()
 
-	// Update the list of stored slack users weekly
-	c := cron.New()
-	c.AddFunc("@weekly", func() {
-		go bot.StoreSlackUsers()
+	// Health checks and metrics
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(writer http.ResponseWriter, request *http.Request) {
+		fmt.Fprintln(writer, "ok")
 	})
-	c.Start()
-
-	// Start go routine to listen to tock users
-	go bot.ListenToSlackUsers()
+	mux.HandleFunc("/readyz", bot.ServeReady)
+	mux.Handle("/metrics", metrics.Default.Handler())
+	// Admin dashboard
+	bot.RegisterDashboard(mux)
 
 	// Start server
-	log.Print("Starting server on port :" + os.Getenv("PORT"))
-	http.ListenAndServe(":"+os.Getenv("PORT"), nil)
+	server := &http.Server{Addr: ":" + os.Getenv("PORT"), Handler: mux}
+	go func() {
+		log.Print("Starting server on port :" + os.Getenv("PORT"))
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// Run the bot until it is asked to stop
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	err := bot.Run(ctx)
+	stop()
 
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	server.Shutdown(shutdownCtx)
+	cancel()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Print("Stopped")
 }
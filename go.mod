@@ -0,0 +1,16 @@
+module github.com/18F/angrytock
+
+go 1.19
+
+require (
+	github.com/cloudfoundry-community/go-cfenv v1.18.0
+	github.com/nlopes/slack v0.6.0
+	github.com/robfig/cron v1.2.0
+	gopkg.in/yaml.v2 v2.4.0
+)
+
+require (
+	github.com/gorilla/websocket v1.2.0 // indirect
+	github.com/mitchellh/mapstructure v1.1.2 // indirect
+	github.com/pkg/errors v0.8.0 // indirect
+)
@@ -5,7 +5,10 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
+	"github.com/18F/angrytock/metrics"
 	"github.com/cloudfoundry-community/go-cfenv"
 )
 
@@ -23,11 +26,18 @@ func FetchData(URL string) []byte {
 	// Get url
 	req, _ := http.NewRequest("GET", URL, nil)
 	req.Header.Set("Authorization", apiAuthToken)
+	started := time.Now()
 	res, err := client.Do(req)
+	metrics.Default.Observe("angrytock_tock_request_duration_seconds", time.Since(started).Seconds())
 	if err != nil {
 		log.Print("Failed to make request")
+		metrics.Default.Inc("angrytock_tock_request_errors_total")
+		return nil
 	}
 	defer res.Body.Close()
+	if res.StatusCode >= 400 {
+		metrics.Default.Inc("angrytock_tock_request_errors_total")
+	}
 	// Read body
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
@@ -38,6 +48,42 @@ func FetchData(URL string) []byte {
 
 }
 
+// CheckURL makes an authenticated request to a tock url and returns an
+// error if it fails or doesn't succeed within the timeout
+func CheckURL(URL string, timeout time.Duration) error {
+	client := &http.Client{Timeout: timeout}
+	req, err := http.NewRequest("GET", URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Token %s", FetchCredential("TOCK_API_TOKEN")))
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("%s returned %s", URL, res.Status)
+	}
+	return nil
+}
+
+// FetchCredential returns a value from the angrytock-credentials service,
+// falling back to the environment when not running on Cloud Foundry.
+// Missing values are returned as an empty string.
+func FetchCredential(name string) string {
+	appEnv, err := cfenv.Current()
+	if err == nil {
+		appService, err := appEnv.Services.WithName("angrytock-credentials")
+		if err == nil {
+			if value, ok := appService.Credentials[name]; ok && value != nil {
+				return fmt.Sprint(value)
+			}
+		}
+	}
+	return os.Getenv(name)
+}
+
 // GenericDataFetcher is a generic function that takes a url string and returns
 // a bytes
 type GenericDataFetcher func(url string) []byte
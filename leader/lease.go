@@ -0,0 +1,136 @@
+// Package leader elects one of several bot instances with a lease kept in a
+// file that all of the instances can reach
+package leader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// record is the content of the lease file
+type record struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+// Lease is a lease on leadership that one holder at a time can hold until
+// it expires or is released
+type Lease struct {
+	path     string
+	holder   string
+	duration time.Duration
+}
+
+// NewLease returns a lease kept in the file at path for a holder. The lease
+// lasts for duration after every renewal.
+func NewLease(path string, holder string, duration time.Duration) *Lease {
+	return &Lease{path: path, holder: holder, duration: duration}
+}
+
+// DefaultHolder names this process by host name and process id
+func DefaultHolder() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// Holder returns the name this lease is held under
+func (lease *Lease) Holder() string {
+	return lease.holder
+}
+
+// Duration returns how long the lease lasts after a renewal
+func (lease *Lease) Duration() time.Duration {
+	return lease.duration
+}
+
+// read returns the current lease record, or an empty record if there is no
+// lease file
+func (lease *Lease) read() (record, error) {
+	var current record
+	data, err := os.ReadFile(lease.path)
+	if os.IsNotExist(err) {
+		return current, nil
+	}
+	if err != nil {
+		return current, err
+	}
+	if len(data) == 0 {
+		return current, nil
+	}
+	return current, json.Unmarshal(data, &current)
+}
+
+// write replaces the lease file atomically
+func (lease *Lease) write(current record) error {
+	data, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(lease.path), filepath.Base(lease.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), lease.path)
+}
+
+// withLock runs fn while holding an exclusive lock next to the lease file
+func (lease *Lease) withLock(fn func() error) error {
+	file, err := os.OpenFile(lease.path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := lock(file); err != nil {
+		return err
+	}
+	defer unlock(file)
+	return fn()
+}
+
+// TryAcquire takes or renews the lease if it is free, expired or already
+// held by this holder. It returns whether this holder has the lease.
+func (lease *Lease) TryAcquire(now time.Time) (bool, error) {
+	acquired := false
+	err := lease.withLock(func() error {
+		current, err := lease.read()
+		if err != nil {
+			return err
+		}
+		if current.Holder != "" && current.Holder != lease.holder && now.Before(current.Expires) {
+			return nil
+		}
+		if err := lease.write(record{Holder: lease.holder, Expires: now.Add(lease.duration)}); err != nil {
+			return err
+		}
+		acquired = true
+		return nil
+	})
+	return acquired, err
+}
+
+// Release gives up the lease if this holder has it so another instance can
+// take over without waiting for it to expire
+func (lease *Lease) Release() error {
+	return lease.withLock(func() error {
+		current, err := lease.read()
+		if err != nil || current.Holder != lease.holder {
+			return err
+		}
+		err = os.Remove(lease.path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	})
+}
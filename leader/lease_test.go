@@ -0,0 +1,56 @@
+package leader
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Check that only one holder gets the lease until it expires
+func TestTryAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.lease")
+	first := NewLease(path, "first", time.Minute)
+	second := NewLease(path, "second", time.Minute)
+	now := time.Now()
+
+	if acquired, err := first.TryAcquire(now); !acquired || err != nil {
+		t.Fatal(acquired, err)
+	}
+	if acquired, err := second.TryAcquire(now.Add(time.Second)); acquired || err != nil {
+		t.Error("expected the second holder to wait", err)
+	}
+	// Renewing keeps the lease
+	if acquired, err := first.TryAcquire(now.Add(30 * time.Second)); !acquired || err != nil {
+		t.Error("expected the first holder to renew", err)
+	}
+	if acquired, _ := second.TryAcquire(now.Add(80 * time.Second)); acquired {
+		t.Error("expected the renewed lease to still be held")
+	}
+	// An expired lease can be taken
+	if acquired, err := second.TryAcquire(now.Add(2 * time.Minute)); !acquired || err != nil {
+		t.Error("expected the second holder to take the expired lease", err)
+	}
+}
+
+// Check that a released lease can be taken right away
+func TestRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.lease")
+	first := NewLease(path, "first", time.Minute)
+	second := NewLease(path, "second", time.Minute)
+	now := time.Now()
+	first.TryAcquire(now)
+
+	// Releasing a lease held by someone else does nothing
+	if err := second.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if acquired, _ := second.TryAcquire(now); acquired {
+		t.Error("expected the lease to still be held")
+	}
+	if err := first.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if acquired, err := second.TryAcquire(now); !acquired || err != nil {
+		t.Error("expected the released lease to be free", err)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package leader
+
+import (
+	"errors"
+	"os"
+)
+
+// lock is not supported without flock
+func lock(file *os.File) error {
+	return errors.New("leader election needs a unix file system")
+}
+
+// unlock does nothing without flock
+func unlock(file *os.File) error {
+	return nil
+}
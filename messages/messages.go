@@ -1,59 +1,223 @@
 package messagesPackage
 
 import (
+	"bytes"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"math/rand"
-	"os"
-	"path/filepath"
 	"strings"
+	"text/template"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
+// MessageData holds the named values that messages can use as template
+// fields, e.g. {{.UserMention}}
+type MessageData struct {
+	// userID is the slack user receiving the message
+	userID        string
+	UserMention   string
+	TockURL       string
+	PeriodStart   string
+	PeriodEnd     string
+	HoursRequired int
+}
+
+// NewMessageData returns message data that mentions a slack user
+func NewMessageData(userID string) MessageData {
+	return MessageData{userID: userID, UserMention: fmt.Sprintf("<@%s>", userID)}
+}
+
 // MessageArray is a torage for one type of message includes methods for choosing a
 // messages from the bunch
 type MessageArray struct {
-	Messages []string `yaml:"responses"`
+	Messages []Message `yaml:"responses"`
+	// Recent is the number of messages a user won't be sent again
+	Recent *int `yaml:"recent"`
+	// Field is a template field every message in a custom category must use
+	Field     string `yaml:"field"`
+	templates []*template.Template
+	recent    *recentMessages
+}
+
+// convertLegacyMessage rewrites a printf style message as a template. A
+// `<@%s>` becomes the user mention and any other `%s` becomes legacyField.
+func convertLegacyMessage(message string, legacyField string) string {
+	if strings.Contains(message, "{{") || !strings.Contains(message, "%") {
+		return message
+	}
+	message = strings.Replace(message, "<@%s>", "{{.UserMention}}", -1)
+	message = strings.Replace(message, "%s", fmt.Sprintf("{{.%s}}", legacyField), -1)
+	return strings.Replace(message, "%%", "%", -1)
+}
+
+// parseTemplates parses every message as a template. Legacy `%s` messages
+// are converted first, using legacyField for the `%s` value.
+func (msgs *MessageArray) parseTemplates(legacyField string) error {
+	msgs.templates = make([]*template.Template, 0, len(msgs.Messages))
+	msgs.recent = &recentMessages{sent: make(map[string][]int)}
+	for _, message := range msgs.Messages {
+		tmpl, err := template.New("message").Parse(convertLegacyMessage(message.Text, legacyField))
+		if err != nil {
+			return err
+		}
+		msgs.templates = append(msgs.templates, tmpl)
+	}
+	return nil
 }
 
-// fetchRandomMessage method for selecting a random message from the created messages
-func (msgs MessageArray) fetchRandomMessage() string {
-	return msgs.Messages[rand.Intn(len(msgs.Messages))]
+// fetchRandomMessage method for selecting a random message from the created
+// messages. Messages with one of the tags are preferred and the user won't
+// get a message they have recently seen.
+func (msgs MessageArray) fetchRandomMessage(user string, tags []string) *template.Template {
+	return msgs.templates[msgs.chooseMessage(user, tags)]
 }
 
-func (msgs MessageArray) GenerateMessage(filler string) string {
-	return fmt.Sprintf(
-		msgs.fetchRandomMessage(),
-		filler,
-	)
+// GenerateMessage renders a random message with the given data. If tags are
+// given, messages with one of those tags are chosen when there are any.
+func (msgs MessageArray) GenerateMessage(data MessageData, tags ...string) string {
+	var message bytes.Buffer
+	tmpl := msgs.fetchRandomMessage(data.userID, tags)
+	if err := tmpl.Execute(&message, data); err != nil {
+		log.Print(err)
+	}
+	return message.String()
 }
 
 // MessageRepository Contains the messages and methods for generating
-// responses for the bot
+// responses for the bot. Every top level entry in messages.yaml with
+// `responses` is a category and can be found by name with Category.
 type MessageRepository struct {
-	Angry    *MessageArray `yaml:"AngryMessages"`
-	Nice     *MessageArray `yaml:"NiceMessages"`
-	Reminder *MessageArray `yaml:"ReminderMessages"`
+	Angry      *MessageArray            `yaml:"-"`
+	Nice       *MessageArray            `yaml:"-"`
+	Reminder   *MessageArray            `yaml:"-"`
+	Categories map[string]*MessageArray `yaml:"-"`
+	// Tones maps tone names to the categories they use
+	Tones map[string]*Tone `yaml:"Tones"`
+	// DateFormat is the Go time layout used for dates in messages
+	DateFormat string `yaml:"DateFormat"`
 }
 
-// InitMessageRepository loads all of the data into a MessageRepository
-// and a MessageRepository struct
-func InitMessageRepository() *MessageRepository {
-	var mrep MessageRepository
-	messageFile := "messages.yaml"
-	workingDir, _ := os.Getwd()
-	if !strings.HasSuffix(workingDir, "messages") {
-		messageFile = filepath.Join("messages", messageFile)
+// UnmarshalYAML reads the settings of a message file and every entry that
+// has `responses` as a category
+func (mrep *MessageRepository) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plainRepository MessageRepository
+	if err := unmarshal((*plainRepository)(mrep)); err != nil {
+		return err
+	}
+	var entries map[string]interface{}
+	if err := unmarshal(&entries); err != nil {
+		return err
+	}
+	mrep.Categories = make(map[string]*MessageArray)
+	for name, entry := range entries {
+		fields, ok := entry.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := fields["responses"]; !ok {
+			continue
+		}
+		// Decode the entry again as a MessageArray
+		data, err := yaml.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		var msgs MessageArray
+		if err := yaml.Unmarshal(data, &msgs); err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		mrep.Categories[name] = &msgs
+	}
+	mrep.Angry = mrep.Categories["AngryMessages"]
+	mrep.Nice = mrep.Categories["NiceMessages"]
+	mrep.Reminder = mrep.Categories["ReminderMessages"]
+	return nil
+}
+
+// Category returns the messages in a category by its name in messages.yaml,
+// e.g. `ThankYouMessages`, or nil if there is no such category
+func (mrep *MessageRepository) Category(name string) *MessageArray {
+	return mrep.Categories[name]
+}
+
+// FormatDate rewrites a tock date (YYYY-MM-DD) using the repository's
+// DateFormat. Dates are returned unchanged if there is no format.
+func (mrep *MessageRepository) FormatDate(date string) string {
+	if mrep.DateFormat == "" {
+		return date
 	}
-	messageFile = filepath.Join(workingDir, messageFile)
-	data, err := ioutil.ReadFile(messageFile)
+	parsedDate, err := time.Parse("2006-01-02", date)
 	if err != nil {
-		log.Fatalf("error: %v", err)
+		return date
+	}
+	return parsedDate.Format(mrep.DateFormat)
+}
+
+// parseMessageRepository reads a message file without validating it
+func parseMessageRepository(data []byte) (*MessageRepository, error) {
+	var mrep MessageRepository
+	if err := yaml.Unmarshal(data, &mrep); err != nil {
+		return nil, err
+	}
+	return &mrep, nil
+}
+
+// merge adds the messages of another file on top of the repository.
+// Responses are added to categories with the same name, tones with the same
+// name are replaced and settings from the other file win.
+func (mrep *MessageRepository) merge(other *MessageRepository) {
+	if mrep.Categories == nil {
+		mrep.Categories = make(map[string]*MessageArray)
+	}
+	for name, msgs := range other.Categories {
+		existing, ok := mrep.Categories[name]
+		if !ok {
+			mrep.Categories[name] = msgs
+			continue
+		}
+		existing.Messages = append(existing.Messages, msgs.Messages...)
+		if msgs.Recent != nil {
+			existing.Recent = msgs.Recent
+		}
+		if msgs.Field != "" {
+			existing.Field = msgs.Field
+		}
+	}
+	if mrep.Tones == nil {
+		mrep.Tones = make(map[string]*Tone)
 	}
-	if yaml.Unmarshal(data, &mrep) != nil {
+	for name, tone := range other.Tones {
+		mrep.Tones[name] = tone
+	}
+	if other.DateFormat != "" {
+		mrep.DateFormat = other.DateFormat
+	}
+	mrep.Angry = mrep.Categories["AngryMessages"]
+	mrep.Nice = mrep.Categories["NiceMessages"]
+	mrep.Reminder = mrep.Categories["ReminderMessages"]
+}
+
+// prepare validates the repository and parses the message templates.
+// Validation problems are returned as ValidationErrors.
+func (mrep *MessageRepository) prepare() error {
+	if errs := mrep.Validate(); len(errs) > 0 {
+		return errs
+	}
+	for _, cat := range mrep.categories() {
+		if err := cat.Messages.parseTemplates(legacyField(cat.Field)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InitMessageRepository loads the default messages embedded in the binary
+// into a MessageRepository
+func InitMessageRepository() *MessageRepository {
+	current, err := buildCatalog(nil)
+	if err != nil {
 		log.Fatalf("error: %v", err)
 	}
-	return &mrep
+	return current.Default
 }
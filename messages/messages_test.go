@@ -1,16 +1,39 @@
 package messagesPackage
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 )
 
 var messageRepo = InitMessageRepository()
 
+var testData = MessageData{
+	userID:        "U1234",
+	UserMention:   "<@U1234>",
+	TockURL:       "https://tock.18f.gov/employees",
+	PeriodStart:   "2014-11-22",
+	PeriodEnd:     "2014-11-28",
+	HoursRequired: 40,
+}
+
+// renderAll renders every message in a MessageArray with the test data
+func renderAll(t *testing.T, msgs *MessageArray) []string {
+	var rendered []string
+	for idx, tmpl := range msgs.templates {
+		var message bytes.Buffer
+		if err := tmpl.Execute(&message, testData); err != nil {
+			t.Errorf("%s: %s", msgs.Messages[idx].Text, err)
+		}
+		rendered = append(rendered, message.String())
+	}
+	return rendered
+}
+
 // Check that messages render properly with user name
 func TestAngryMessagesMessages(t *testing.T) {
-	for _, message := range messageRepo.Angry.Messages {
-		if !strings.Contains(message, "<@%s>") {
+	for _, message := range renderAll(t, messageRepo.Angry) {
+		if !strings.Contains(message, testData.UserMention) {
 			t.Errorf(message)
 		}
 	}
@@ -18,17 +41,17 @@ func TestAngryMessagesMessages(t *testing.T) {
 
 // Check that messages render properly with user name
 func TestNiceMessagesMessages(t *testing.T) {
-	for _, message := range messageRepo.Nice.Messages {
-		if !strings.Contains(message, "<@%s>") {
+	for _, message := range renderAll(t, messageRepo.Nice) {
+		if !strings.Contains(message, testData.UserMention) {
 			t.Errorf(message)
 		}
 	}
 }
 
-// Check that messages include a %s
+// Check that messages include the tock url
 func TestReminderMessagesMessages(t *testing.T) {
-	for _, message := range messageRepo.Reminder.Messages {
-		if !strings.Contains(message, "%s") {
+	for _, message := range renderAll(t, messageRepo.Reminder) {
+		if !strings.Contains(message, testData.TockURL) {
 			t.Errorf(message)
 		}
 	}
@@ -36,9 +59,31 @@ func TestReminderMessagesMessages(t *testing.T) {
 
 // Check that randomly generated messages work
 func TestRandomMessages(t *testing.T) {
-	message := messageRepo.Angry.fetchRandomMessage()
-	if !strings.Contains(message, "<@%s>") {
+	message := messageRepo.Angry.GenerateMessage(testData)
+	if !strings.Contains(message, testData.UserMention) {
 		t.Errorf(message)
 	}
 
 }
+
+// Check that legacy printf style messages are converted to templates
+func TestLegacyMessages(t *testing.T) {
+	msgs := MessageArray{Messages: []Message{
+		{Text: "<@%s>! 100%% of timesheets, please"},
+		{Text: "Please fill out your timesheet, %s"},
+		{Text: "Thanks {{.UserMention}}, that's 100%"},
+	}}
+	if err := msgs.parseTemplates("TockURL"); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"<@U1234>! 100% of timesheets, please",
+		"Please fill out your timesheet, https://tock.18f.gov/employees",
+		"Thanks <@U1234>, that's 100%",
+	}
+	for idx, message := range renderAll(t, &msgs) {
+		if message != expected[idx] {
+			t.Errorf(message)
+		}
+	}
+}
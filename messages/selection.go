@@ -0,0 +1,166 @@
+package messagesPackage
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// defaultRecentLimit is the number of messages a user won't see again when
+// a category doesn't set `recent`
+const defaultRecentLimit = 3
+
+// Message is one response in a category. In messages.yaml it can be written
+// as a plain string or as a mapping with text, weight and tags.
+type Message struct {
+	Text string `yaml:"text"`
+	// Weight makes a message more or less likely to be chosen. Messages
+	// without a weight have a weight of 1 and a weight of 0 disables them.
+	Weight *int     `yaml:"weight"`
+	Tags   []string `yaml:"tags"`
+}
+
+// UnmarshalYAML reads a message written as a string or as a mapping
+func (message *Message) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var text string
+	if err := unmarshal(&text); err == nil {
+		*message = Message{Text: text}
+		return nil
+	}
+	type plainMessage Message
+	return unmarshal((*plainMessage)(message))
+}
+
+// weight returns the message weight, defaulting to 1
+func (message Message) weight() int {
+	if message.Weight == nil {
+		return 1
+	}
+	return *message.Weight
+}
+
+// hasTag checks if the message has any of the tags
+func (message Message) hasTag(tags []string) bool {
+	for _, tag := range tags {
+		for _, messageTag := range message.Tags {
+			if tag == messageTag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// random is a seeded source shared by all message arrays. rand.Rand is not
+// safe for concurrent use so it is guarded by randomMutex.
+var (
+	random      = rand.New(rand.NewSource(time.Now().UnixNano()))
+	randomMutex sync.Mutex
+)
+
+// randomIntn returns a random number in [0,n)
+func randomIntn(n int) int {
+	randomMutex.Lock()
+	defer randomMutex.Unlock()
+	return random.Intn(n)
+}
+
+// recentMessages remembers the last messages sent to each user
+type recentMessages struct {
+	sync.Mutex
+	sent map[string][]int
+}
+
+// seen checks if a user was recently sent a message
+func (recent *recentMessages) seen(user string, idx int) bool {
+	recent.Lock()
+	defer recent.Unlock()
+	for _, sentIdx := range recent.sent[user] {
+		if sentIdx == idx {
+			return true
+		}
+	}
+	return false
+}
+
+// remember records a message sent to a user, keeping at most limit messages
+func (recent *recentMessages) remember(user string, idx int, limit int) {
+	recent.Lock()
+	defer recent.Unlock()
+	sent := append(recent.sent[user], idx)
+	if len(sent) > limit {
+		sent = sent[len(sent)-limit:]
+	}
+	recent.sent[user] = sent
+}
+
+// recentLimit returns how many messages a user won't see again. There is
+// always at least one message left to choose.
+func (msgs MessageArray) recentLimit() int {
+	limit := defaultRecentLimit
+	if msgs.Recent != nil {
+		limit = *msgs.Recent
+	}
+	if limit > len(msgs.Messages)-1 {
+		limit = len(msgs.Messages) - 1
+	}
+	return limit
+}
+
+// candidates lists the messages that can be sent to a user, preferring
+// messages with one of the tags that the user hasn't seen recently
+func (msgs MessageArray) candidates(user string, tags []string) []int {
+	var all, tagged []int
+	for idx, message := range msgs.Messages {
+		if message.weight() <= 0 {
+			continue
+		}
+		all = append(all, idx)
+		if message.hasTag(tags) {
+			tagged = append(tagged, idx)
+		}
+	}
+	candidates := all
+	if len(tagged) > 0 {
+		candidates = tagged
+	}
+	if user == "" || msgs.recent == nil {
+		return candidates
+	}
+	var unseen []int
+	for _, idx := range candidates {
+		if !msgs.recent.seen(user, idx) {
+			unseen = append(unseen, idx)
+		}
+	}
+	if len(unseen) == 0 {
+		return candidates
+	}
+	return unseen
+}
+
+// chooseMessage picks a weighted random message for a user and remembers it
+func (msgs MessageArray) chooseMessage(user string, tags []string) int {
+	candidates := msgs.candidates(user, tags)
+	if len(candidates) == 0 {
+		// Every message is disabled, so fall back to a plain random choice
+		return randomIntn(len(msgs.Messages))
+	}
+	total := 0
+	for _, idx := range candidates {
+		total += msgs.Messages[idx].weight()
+	}
+	choice := randomIntn(total)
+	chosen := candidates[len(candidates)-1]
+	for _, idx := range candidates {
+		choice -= msgs.Messages[idx].weight()
+		if choice < 0 {
+			chosen = idx
+			break
+		}
+	}
+	if user != "" && msgs.recent != nil {
+		msgs.recent.remember(user, chosen, msgs.recentLimit())
+	}
+	return chosen
+}
@@ -0,0 +1,72 @@
+package messagesPackage
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+const selectionTestMessages = `
+recent: 2
+responses:
+  - "{{.UserMention}} one"
+  - text: "{{.UserMention}} two"
+    weight: 0
+  - text: "{{.UserMention}} three"
+    tags: [gentle]
+  - text: "{{.UserMention}} four"
+    weight: 5
+    tags: [spicy]
+`
+
+// loadSelectionMessages parses the selection test messages
+func loadSelectionMessages(t *testing.T) *MessageArray {
+	var msgs MessageArray
+	if err := yaml.Unmarshal([]byte(selectionTestMessages), &msgs); err != nil {
+		t.Fatal(err)
+	}
+	if err := msgs.parseTemplates("UserMention"); err != nil {
+		t.Fatal(err)
+	}
+	return &msgs
+}
+
+// Check that messages can be written as strings or mappings
+func TestMessageYAML(t *testing.T) {
+	msgs := loadSelectionMessages(t)
+	if len(msgs.Messages) != 4 || msgs.Messages[0].weight() != 1 || msgs.Messages[3].weight() != 5 {
+		t.Error(msgs.Messages)
+	}
+	if !msgs.Messages[2].hasTag([]string{"gentle"}) {
+		t.Error(msgs.Messages[2])
+	}
+}
+
+// Check that disabled messages are never chosen and users don't see the
+// same message within the recent limit
+func TestChooseMessageNoRepeats(t *testing.T) {
+	msgs := loadSelectionMessages(t)
+	var sent []int
+	for i := 0; i < 30; i++ {
+		idx := msgs.chooseMessage("U1234", nil)
+		if idx == 1 {
+			t.Fatal("chose a message with a weight of 0")
+		}
+		sent = append(sent, idx)
+	}
+	for i := 1; i < len(sent); i++ {
+		if sent[i] == sent[i-1] {
+			t.Fatalf("repeated message %d: %v", sent[i], sent)
+		}
+	}
+}
+
+// Check that tagged messages are preferred
+func TestChooseTaggedMessage(t *testing.T) {
+	msgs := loadSelectionMessages(t)
+	for i := 0; i < 10; i++ {
+		if idx := msgs.chooseMessage("", []string{"gentle"}); idx != 2 {
+			t.Error(idx)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package messagesPackage
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// embeddedMessages holds messages.yaml and its translations so the bot
+// always has a default catalog, wherever it is run from
+//
+//go:embed *.yaml
+var embeddedMessages embed.FS
+
+// defaultMessageFile is the name of the embedded default messages
+const defaultMessageFile = "messages.yaml"
+
+// sourceClient fetches message files served over http
+var sourceClient = &http.Client{Timeout: 10 * time.Second}
+
+// catalog holds the default messages and the messages for each locale
+type catalog struct {
+	Default *MessageRepository
+	Locales map[string]*MessageRepository
+}
+
+// MessageStore holds the current MessageRepository and swaps it atomically
+// when the messages are reloaded. Messages generated from a repository
+// returned by Current are unaffected by later reloads.
+//
+// The messages embedded in the binary are loaded first and each source, a
+// file path or an http(s) URL, is merged on top in order. Translations live
+// next to a file and are named by locale, e.g. messages.es.yaml or
+// local.pt-br.yaml for local.yaml.
+type MessageStore struct {
+	sources   []string
+	current   atomic.Value
+	fileState string
+	// reloadMutex serializes reloads
+	reloadMutex sync.Mutex
+}
+
+// NewMessageStore loads the embedded messages and the sources into a new
+// MessageStore
+func NewMessageStore(sources []string) (*MessageStore, error) {
+	store := &MessageStore{sources: sources}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// InitMessageStore loads the embedded messages and the sources into a
+// MessageStore
+func InitMessageStore(sources []string) *MessageStore {
+	store, err := NewMessageStore(sources)
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	return store
+}
+
+// Current returns the default MessageRepository currently in use
+func (store *MessageStore) Current() *MessageRepository {
+	return store.current.Load().(*catalog).Default
+}
+
+// Localized returns the MessageRepository for a locale such as `es` or
+// `pt-BR`, falling back from the full locale to the language and then to the
+// default messages
+func (store *MessageStore) Localized(locale string) *MessageRepository {
+	current := store.current.Load().(*catalog)
+	locale = normalizeLocale(locale)
+	for locale != "" {
+		if mrep, ok := current.Locales[locale]; ok {
+			return mrep
+		}
+		idx := strings.LastIndex(locale, "-")
+		if idx < 0 {
+			break
+		}
+		locale = locale[:idx]
+	}
+	return current.Default
+}
+
+// Locales returns the locales that have translations
+func (store *MessageStore) Locales() []string {
+	var locales []string
+	for locale := range store.current.Load().(*catalog).Locales {
+		locales = append(locales, locale)
+	}
+	return locales
+}
+
+// normalizeLocale lowercases a locale and uses dashes, e.g. `pt_BR` is `pt-br`
+func normalizeLocale(locale string) string {
+	return strings.Replace(strings.ToLower(strings.TrimSpace(locale)), "_", "-", -1)
+}
+
+// isURL checks if a source is served over http
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// sourceFiles returns the files for a source keyed by locale. The source
+// itself has an empty locale. URLs have no translations.
+func sourceFiles(source string) map[string]string {
+	files := map[string]string{"": source}
+	if isURL(source) {
+		return files
+	}
+	extension := filepath.Ext(source)
+	base := strings.TrimSuffix(source, extension)
+	matches, _ := filepath.Glob(base + ".*" + extension)
+	for _, match := range matches {
+		locale := strings.TrimSuffix(strings.TrimPrefix(match, base+"."), extension)
+		files[normalizeLocale(locale)] = match
+	}
+	return files
+}
+
+// readSource reads a message file from disk or over http
+func readSource(source string) ([]byte, error) {
+	if !isURL(source) {
+		return ioutil.ReadFile(source)
+	}
+	res, err := sourceClient.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", source, res.Status)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+// buildCatalog loads the embedded messages, merges the sources on top in
+// order and validates the result
+func buildCatalog(sources []string) (*catalog, error) {
+	// Load the embedded messages keyed by locale
+	repos := make(map[string]*MessageRepository)
+	names, _ := embeddedMessages.ReadDir(".")
+	for _, entry := range names {
+		data, err := embeddedMessages.ReadFile(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		mrep, err := parseMessageRepository(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", entry.Name(), err)
+		}
+		locale := strings.TrimSuffix(strings.TrimPrefix(entry.Name(), "messages"), ".yaml")
+		repos[normalizeLocale(strings.TrimPrefix(locale, "."))] = mrep
+	}
+	for _, source := range sources {
+		for locale, file := range sourceFiles(source) {
+			data, err := readSource(file)
+			if err != nil {
+				return nil, err
+			}
+			overlay, err := parseMessageRepository(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", file, err)
+			}
+			if mrep, ok := repos[locale]; ok {
+				mrep.merge(overlay)
+			} else {
+				repos[locale] = overlay
+			}
+		}
+	}
+
+	newCatalog := &catalog{Locales: make(map[string]*MessageRepository)}
+	for locale, mrep := range repos {
+		if err := mrep.prepare(); err != nil {
+			name := defaultMessageFile
+			if locale != "" {
+				name = fmt.Sprintf("messages for %s", locale)
+			}
+			return nil, fmt.Errorf("%s:\n%w", name, err)
+		}
+		if locale == "" {
+			newCatalog.Default = mrep
+		} else {
+			newCatalog.Locales[locale] = mrep
+		}
+	}
+	if newCatalog.Default == nil {
+		return nil, fmt.Errorf("no default messages")
+	}
+	return newCatalog, nil
+}
+
+// currentFileState describes the names and modification times of the
+// message files so changes can be detected. URLs are only read on reload.
+func (store *MessageStore) currentFileState() string {
+	var state []string
+	for _, source := range store.sources {
+		for _, file := range sourceFiles(source) {
+			if isURL(file) {
+				continue
+			}
+			info, err := os.Stat(file)
+			if err != nil {
+				continue
+			}
+			state = append(state, fmt.Sprintf("%s@%d", file, info.ModTime().UnixNano()))
+		}
+	}
+	// Map iteration order is random
+	sort.Strings(state)
+	return strings.Join(state, ",")
+}
+
+// Reload reads the messages again. If any file is invalid the previous
+// messages are kept and the error is returned.
+func (store *MessageStore) Reload() error {
+	store.reloadMutex.Lock()
+	defer store.reloadMutex.Unlock()
+	// Don't retry the same bad files on every check
+	store.fileState = store.currentFileState()
+	newCatalog, err := buildCatalog(store.sources)
+	if err != nil {
+		return err
+	}
+	store.current.Store(newCatalog)
+	return nil
+}
+
+// changed checks if the message files were modified since the last reload
+func (store *MessageStore) changed() bool {
+	store.reloadMutex.Lock()
+	defer store.reloadMutex.Unlock()
+	return store.currentFileState() != store.fileState
+}
+
+// Watch checks the message files for changes every interval and reloads
+// them until the context is done
+func (store *MessageStore) Watch(ctx context.Context, interval time.Duration) {
+	if len(store.sources) == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if !store.changed() {
+				continue
+			}
+			if err := store.Reload(); err != nil {
+				log.Printf("Keeping previous messages, unable to reload: %s", err)
+			} else {
+				log.Println("Reloaded messages")
+			}
+		}
+	}()
+}
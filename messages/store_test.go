@@ -0,0 +1,152 @@
+package messagesPackage
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const storeTestMessages = `
+StoreTestMessages:
+  responses:
+    - "{{.UserMention}}, %s"
+`
+
+// Check that a bad message file is rejected and the previous messages kept
+func TestMessageStoreReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	messageFile := filepath.Join(dir, "local.yaml")
+
+	ioutil.WriteFile(messageFile, []byte(fmt.Sprintf(storeTestMessages, "first")), 0644)
+	store, err := NewMessageStore([]string{messageFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := store.Current()
+
+	ioutil.WriteFile(messageFile, []byte("StoreTestMessages:\n  responses: []\n"), 0644)
+	if err := store.Reload(); err == nil {
+		t.Error("expected an empty category to be rejected")
+	}
+	if store.Current() != previous {
+		t.Error("previous messages should be kept after a bad reload")
+	}
+
+	ioutil.WriteFile(messageFile, []byte(fmt.Sprintf(storeTestMessages, "second")), 0644)
+	if err := store.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	message := store.Current().Category("StoreTestMessages").GenerateMessage(testData)
+	if message != "<@U1234>, second" {
+		t.Error(message)
+	}
+	if previous.Category("StoreTestMessages").GenerateMessage(testData) != "<@U1234>, first" {
+		t.Error("messages from the previous repository should not change")
+	}
+}
+
+// Check that files are merged on top of the embedded messages in order
+func TestMessageStoreMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	localFile := filepath.Join(dir, "local.yaml")
+	ioutil.WriteFile(localFile, []byte("AngryMessages:\n  responses:\n    - '{{.UserMention}}, local'\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "local.es.yaml"), []byte("DateFormat: 2006\n"), 0644)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "DateFormat: Jan 2, 2006\nAngryMessages:\n  responses:\n    - '{{.UserMention}}, remote'\n")
+	}))
+	defer server.Close()
+
+	store, err := NewMessageStore([]string{localFile, server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	angry := store.Current().Angry.Messages
+	embedded := InitMessageRepository().Angry.Messages
+	if len(angry) != len(embedded)+2 || angry[len(angry)-2].Text != "{{.UserMention}}, local" || angry[len(angry)-1].Text != "{{.UserMention}}, remote" {
+		t.Error(angry[len(embedded):])
+	}
+	if date := store.Current().FormatDate("2014-11-22"); date != "Nov 22, 2014" {
+		t.Error(date)
+	}
+	if date := store.Localized("es").FormatDate("2014-11-22"); date != "2014" {
+		t.Error(date)
+	}
+}
+
+// Check that locales fall back from the full locale to the language and then
+// to the default messages
+func TestMessageStoreLocalized(t *testing.T) {
+	store, err := NewMessageStore(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spanish := store.Localized("es")
+	if spanish == store.Current() || store.Localized("es-MX") != spanish || store.Localized("es_mx") != spanish {
+		t.Error("expected es-MX to use the Spanish messages")
+	}
+	if store.Localized("fr-FR") != store.Current() || store.Localized("") != store.Current() {
+		t.Error("expected unknown locales to use the default messages")
+	}
+	if date := spanish.FormatDate("2014-11-22"); date != "22/11/2014" {
+		t.Error(date)
+	}
+	if date := store.Current().FormatDate("2014-11-22"); date != "2014-11-22" {
+		t.Error(date)
+	}
+}
+
+// Check that changed files are reloaded until the watcher is stopped
+func TestMessageStoreWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	messageFile := filepath.Join(dir, "local.yaml")
+	ioutil.WriteFile(messageFile, []byte(fmt.Sprintf(storeTestMessages, "first")), 0644)
+	store, err := NewMessageStore([]string{messageFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	generate := func() string {
+		return store.Current().Category("StoreTestMessages").GenerateMessage(testData)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	store.Watch(ctx, 5*time.Millisecond)
+	ioutil.WriteFile(messageFile, []byte(fmt.Sprintf(storeTestMessages, "second")), 0644)
+	// Make sure the modification time changes on coarse file systems
+	os.Chtimes(messageFile, time.Now(), time.Now().Add(time.Second))
+	deadline := time.Now().Add(time.Second)
+	for generate() != "<@U1234>, second" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if message := generate(); message != "<@U1234>, second" {
+		t.Fatal(message)
+	}
+
+	cancel()
+	// Let a check that already started finish
+	time.Sleep(20 * time.Millisecond)
+	ioutil.WriteFile(messageFile, []byte(fmt.Sprintf(storeTestMessages, "third")), 0644)
+	os.Chtimes(messageFile, time.Now(), time.Now().Add(2*time.Second))
+	time.Sleep(50 * time.Millisecond)
+	if message := generate(); message != "<@U1234>, second" {
+		t.Error("messages were reloaded after the watcher stopped:", message)
+	}
+}
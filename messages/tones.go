@@ -0,0 +1,87 @@
+package messagesPackage
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Tone maps the bot's angry, nice and reminder messages to message
+// categories by name and prefers messages with one of the tags
+type Tone struct {
+	Angry    string   `yaml:"angry"`
+	Nice     string   `yaml:"nice"`
+	Reminder string   `yaml:"reminder"`
+	Tags     []string `yaml:"tags"`
+}
+
+// ToneMessages are the messages used by a tone
+type ToneMessages struct {
+	Angry    *MessageArray
+	Nice     *MessageArray
+	Reminder *MessageArray
+	Tags     []string
+}
+
+// toneCategory returns the named category or fallback if it is missing
+func (mrep *MessageRepository) toneCategory(name string, fallback *MessageArray) *MessageArray {
+	if msgs := mrep.Category(name); msgs != nil && len(msgs.Messages) > 0 {
+		return msgs
+	}
+	return fallback
+}
+
+// Tone returns the messages for a tone. Unknown tones use the angry, nice
+// and reminder messages without tags.
+func (mrep *MessageRepository) Tone(name string) *ToneMessages {
+	return mrep.TranslatedTone(name, mrep)
+}
+
+// TranslatedTone returns the messages for a tone in a translation. Tones the
+// translation doesn't define use the tone from base, usually the default
+// messages, and categories missing from the translation come from base, so
+// users keep their tone in every language.
+func (mrep *MessageRepository) TranslatedTone(name string, base *MessageRepository) *ToneMessages {
+	toneMessages := &ToneMessages{Angry: mrep.Angry, Nice: mrep.Nice, Reminder: mrep.Reminder}
+	tone := mrep.Tones[name]
+	if tone == nil {
+		tone = base.Tones[name]
+	}
+	if tone == nil {
+		return toneMessages
+	}
+	toneMessages.Angry = mrep.toneCategory(tone.Angry, base.toneCategory(tone.Angry, mrep.Angry))
+	toneMessages.Nice = mrep.toneCategory(tone.Nice, base.toneCategory(tone.Nice, mrep.Nice))
+	toneMessages.Reminder = mrep.toneCategory(tone.Reminder, base.toneCategory(tone.Reminder, mrep.Reminder))
+	toneMessages.Tags = tone.Tags
+	return toneMessages
+}
+
+// ToneNames returns the names of the tones, sorted
+func (mrep *MessageRepository) ToneNames() []string {
+	var names []string
+	for name := range mrep.Tones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateTones checks that tones only use categories that have messages
+func (mrep *MessageRepository) validateTones() ValidationErrors {
+	var errs ValidationErrors
+	for _, name := range mrep.ToneNames() {
+		tone := mrep.Tones[name]
+		if tone == nil {
+			continue
+		}
+		for _, categoryName := range []string{tone.Angry, tone.Nice, tone.Reminder} {
+			if categoryName == "" {
+				continue
+			}
+			if msgs := mrep.Category(categoryName); msgs == nil || len(msgs.Messages) == 0 {
+				errs = append(errs, fmt.Errorf("Tones %s: %s has no responses", name, categoryName))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,52 @@
+package messagesPackage
+
+import "testing"
+
+// Check that tones map to the configured categories
+func TestTone(t *testing.T) {
+	professional := messageRepo.Tone("professional")
+	if professional.Angry != messageRepo.Category("ProfessionalMessages") || professional.Nice != messageRepo.Nice {
+		t.Error(professional)
+	}
+	playful := messageRepo.Tone("playful")
+	if playful.Angry != messageRepo.Angry || len(playful.Tags) != 1 {
+		t.Error(playful)
+	}
+	unknown := messageRepo.Tone("grumpy")
+	if unknown.Angry != messageRepo.Angry || unknown.Reminder != messageRepo.Reminder || unknown.Tags != nil {
+		t.Error(unknown)
+	}
+}
+
+// Check that tones using missing categories are reported
+func TestValidateTones(t *testing.T) {
+	mrep := MessageRepository{
+		Angry: &MessageArray{Messages: []Message{{Text: "{{.UserMention}}!"}}},
+		Tones: map[string]*Tone{"professional": &Tone{Angry: "PoliteMessages"}},
+	}
+	if errs := mrep.validateTones(); len(errs) != 1 {
+		t.Error(errs)
+	}
+}
+
+// Check that translations without tones use the default tones with their
+// own categories where they have them
+func TestTranslatedTone(t *testing.T) {
+	translation := &MessageRepository{
+		Angry:    &MessageArray{Messages: []Message{{Text: "{{.UserMention}}!"}}},
+		Nice:     &MessageArray{Messages: []Message{{Text: "{{.UserMention}} :)"}}},
+		Reminder: &MessageArray{Messages: []Message{{Text: "{{.TockURL}}"}}},
+	}
+	professional := translation.TranslatedTone("professional", messageRepo)
+	if professional.Angry != messageRepo.Category("ProfessionalMessages") || professional.Nice != translation.Nice {
+		t.Error(professional)
+	}
+	playful := translation.TranslatedTone("playful", messageRepo)
+	if playful.Angry != translation.Angry || len(playful.Tags) != 1 {
+		t.Error(playful)
+	}
+	snarky := translation.TranslatedTone("snarky", messageRepo)
+	if snarky.Angry != translation.Angry || snarky.Reminder != translation.Reminder {
+		t.Error(snarky)
+	}
+}
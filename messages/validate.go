@@ -0,0 +1,160 @@
+package messagesPackage
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// ValidationErrors is a list of problems found in a message catalog
+type ValidationErrors []error
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for idx, err := range errs {
+		messages[idx] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// category describes a message category and the field that every one of its
+// messages must use
+type category struct {
+	Name     string
+	Messages *MessageArray
+	Field    string
+}
+
+// builtinFields are the fields required by the categories the bot always uses
+var builtinFields = map[string]string{
+	"AngryMessages":    "UserMention",
+	"NiceMessages":     "UserMention",
+	"ReminderMessages": "TockURL",
+}
+
+// categories lists the message categories in the repository with the
+// categories every file needs first and custom categories sorted by name
+func (mrep *MessageRepository) categories() []category {
+	categories := []category{
+		{"AngryMessages", mrep.Angry, builtinFields["AngryMessages"]},
+		{"NiceMessages", mrep.Nice, builtinFields["NiceMessages"]},
+		{"ReminderMessages", mrep.Reminder, builtinFields["ReminderMessages"]},
+	}
+	var names []string
+	for name := range mrep.Categories {
+		if _, ok := builtinFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		msgs := mrep.Categories[name]
+		categories = append(categories, category{name, msgs, msgs.Field})
+	}
+	return categories
+}
+
+// legacyField returns the field that fills `%s` in legacy messages
+func legacyField(field string) string {
+	if field == "" {
+		return "UserMention"
+	}
+	return field
+}
+
+// validationData fills every template field with a recognizable value
+var validationData = MessageData{
+	UserMention:   "<@UVALIDATE>",
+	TockURL:       "https://tock.example.gov/validate",
+	PeriodStart:   "2000-01-01",
+	PeriodEnd:     "2000-01-07",
+	HoursRequired: 40,
+}
+
+// fieldValue returns the validation value of a template field and if the
+// field exists
+func fieldValue(field string) (string, bool) {
+	switch field {
+	case "UserMention":
+		return validationData.UserMention, true
+	case "TockURL":
+		return validationData.TockURL, true
+	case "PeriodStart":
+		return validationData.PeriodStart, true
+	case "PeriodEnd":
+		return validationData.PeriodEnd, true
+	case "HoursRequired":
+		return fmt.Sprint(validationData.HoursRequired), true
+	}
+	return "", false
+}
+
+// slackTokenFinder finds slack mentions, channel links, special mentions
+// such as `<!here>` and links, and their closing `>` if there is one
+var slackTokenFinder = regexp.MustCompile(`<(@|#|!|https?://|mailto:)[^<>\n]*(>?)`)
+
+// checkMarkup checks that slack links and mentions are closed and that bold
+// and code markers come in pairs. Other `<` and `>`, e.g. `< 40 hours`, are
+// plain text.
+func checkMarkup(message string) error {
+	for _, found := range slackTokenFinder.FindAllStringSubmatch(message, -1) {
+		if found[2] == "" {
+			return fmt.Errorf("`<%s` without a matching `>`", found[1])
+		}
+	}
+	for _, marker := range []string{"*", "`"} {
+		if strings.Count(message, marker)%2 != 0 {
+			return fmt.Errorf("unbalanced %s", marker)
+		}
+	}
+	return nil
+}
+
+// validateMessage checks that a message parses, renders, includes the
+// category field and has balanced slack markup
+func validateMessage(message string, field string) error {
+	converted := convertLegacyMessage(message, legacyField(field))
+	tmpl, err := template.New("message").Parse(converted)
+	if err != nil {
+		return err
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, validationData); err != nil {
+		return err
+	}
+	if field == "" {
+		return checkMarkup(rendered.String())
+	}
+	value, ok := fieldValue(field)
+	if !ok {
+		return fmt.Errorf("unknown field %s", field)
+	}
+	if !strings.Contains(rendered.String(), value) {
+		return fmt.Errorf("missing {{.%s}}", field)
+	}
+	return checkMarkup(rendered.String())
+}
+
+// Validate checks every category in the repository and returns all of the
+// problems found
+func (mrep *MessageRepository) Validate() ValidationErrors {
+	var errs ValidationErrors
+	for _, cat := range mrep.categories() {
+		if cat.Messages == nil || len(cat.Messages.Messages) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no responses", cat.Name))
+			continue
+		}
+		for idx, message := range cat.Messages.Messages {
+			if err := validateMessage(message.Text, cat.Field); err != nil {
+				errs = append(errs, fmt.Errorf("%s response %d: %s", cat.Name, idx+1, err))
+			}
+			if message.weight() < 0 {
+				errs = append(errs, fmt.Errorf("%s response %d: negative weight", cat.Name, idx+1))
+			}
+		}
+	}
+	return append(errs, mrep.validateTones()...)
+}
@@ -0,0 +1,81 @@
+package messagesPackage
+
+import (
+	"strings"
+	"testing"
+)
+
+// Check that the bundled messages pass validation
+func TestValidateMessageFile(t *testing.T) {
+	if errs := messageRepo.Validate(); len(errs) > 0 {
+		t.Error(errs)
+	}
+}
+
+var validateTests = []struct {
+	Message string
+	Field   string
+	Error   string
+}{
+	{"{{.UserMention}}, fill out tock", "UserMention", ""},
+	{"<@%s>, fill out tock", "UserMention", ""},
+	{"Fill out tock", "UserMention", "missing {{.UserMention}}"},
+	{"Fill out tock {{.UserMention}", "UserMention", "bad character"},
+	{"Fill out tock {{.UserMentoin}}", "UserMention", "UserMentoin"},
+	{"{{.UserMention}}, <https://tock.18f.gov|fill out tock", "UserMention", "without a matching `>`"},
+	{"{{.UserMention}}, *fill out tock", "UserMention", "unbalanced *"},
+	{"{{.UserMention}}, log < 40 hours or > 60 hours", "UserMention", ""},
+	{"{{.UserMention}} and <!here>, <#C1234|general>", "UserMention", ""},
+	{"{{.UserMention}}, ask <@U5678 about tock", "UserMention", "`<@` without a matching `>`"},
+	{"{{.UserMention}}, <!channel <@U5678>", "UserMention", "`<!` without a matching `>`"},
+	{"Fill out tock {{.TockURL}}", "TockURL", ""},
+}
+
+// Check that malformed messages are reported
+func TestValidateMessage(t *testing.T) {
+	for _, test := range validateTests {
+		err := validateMessage(test.Message, test.Field)
+		switch {
+		case test.Error == "" && err != nil:
+			t.Errorf("%s: %s", test.Message, err)
+		case test.Error != "" && (err == nil || !strings.Contains(err.Error(), test.Error)):
+			t.Errorf("%s: expected %s, got %v", test.Message, test.Error, err)
+		}
+	}
+}
+
+// Check that empty categories are reported
+func TestValidateEmptyCategory(t *testing.T) {
+	mrep := MessageRepository{
+		Angry:    &MessageArray{Messages: []Message{{Text: "{{.UserMention}}!"}}},
+		Nice:     &MessageArray{},
+		Reminder: nil,
+	}
+	if errs := mrep.Validate(); len(errs) != 2 {
+		t.Error(errs)
+	}
+}
+
+// Check that custom categories are loaded by name and their field is checked
+func TestCustomCategories(t *testing.T) {
+	if thankYou := messageRepo.Category("ThankYouMessages"); thankYou == nil || len(thankYou.templates) == 0 {
+		t.Error("expected the ThankYouMessages category")
+	}
+	if messageRepo.Category("MissingMessages") != nil {
+		t.Error("expected a missing category to be nil")
+	}
+	mrep := MessageRepository{
+		Angry:    &MessageArray{Messages: []Message{{Text: "{{.UserMention}}!"}}},
+		Nice:     &MessageArray{Messages: []Message{{Text: "{{.UserMention}}!"}}},
+		Reminder: &MessageArray{Messages: []Message{{Text: "{{.TockURL}}"}}},
+		Categories: map[string]*MessageArray{
+			"FinalWarning":    &MessageArray{Field: "UserMention", Messages: []Message{{Text: "Last chance!"}}},
+			"HolidayReminder": &MessageArray{Messages: []Message{{Text: "Happy holidays"}}},
+			"TypoMessages":    &MessageArray{Field: "UserMentoin", Messages: []Message{{Text: "{{.UserMention}}"}}},
+		},
+	}
+	errs := mrep.Validate()
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), "FinalWarning") || !strings.Contains(errs[1].Error(), "unknown field") {
+		t.Error(errs)
+	}
+}
@@ -0,0 +1,148 @@
+// Package metrics keeps counters, summaries and gauges and writes them in
+// the Prometheus text format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Default is the registry the bot records to and serves on /metrics
+var Default = NewRegistry()
+
+// family is a metric name with its help text, type and labeled values
+type family struct {
+	help   string
+	kind   string
+	values map[string]float64
+	gauges map[string]func() float64
+}
+
+// Registry holds metric families by name
+type Registry struct {
+	mutex    sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// labelString formats key, value pairs as Prometheus labels, e.g.
+// `{kind="dm"}`
+func labelString(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for idx := 0; idx+1 < len(labels); idx += 2 {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[idx+1])
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[idx], value))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// family returns a metric family, creating it if needed. The caller must
+// hold the mutex.
+func (registry *Registry) family(name string, kind string) *family {
+	metric, ok := registry.families[name]
+	if !ok {
+		metric = &family{
+			kind:   kind,
+			values: make(map[string]float64),
+			gauges: make(map[string]func() float64),
+		}
+		registry.families[name] = metric
+	}
+	return metric
+}
+
+// Describe sets the help text of a metric
+func (registry *Registry) Describe(name string, help string) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.family(name, "untyped").help = help
+}
+
+// Inc adds one to a counter. Labels are given as key, value pairs.
+func (registry *Registry) Inc(name string, labels ...string) {
+	registry.Add(name, 1, labels...)
+}
+
+// Add adds a value to a counter
+func (registry *Registry) Add(name string, value float64, labels ...string) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	metric := registry.family(name, "counter")
+	metric.kind = "counter"
+	metric.values[labelString(labels)] += value
+}
+
+// Observe records a value, such as a request duration, in a summary
+func (registry *Registry) Observe(name string, value float64, labels ...string) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	metric := registry.family(name, "summary")
+	metric.kind = "summary"
+	key := labelString(labels)
+	metric.values["_sum"+key] += value
+	metric.values["_count"+key]++
+}
+
+// GaugeFunc registers a function that returns the current value of a gauge
+func (registry *Registry) GaugeFunc(name string, gauge func() float64, labels ...string) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	metric := registry.family(name, "gauge")
+	metric.kind = "gauge"
+	metric.gauges[labelString(labels)] = gauge
+}
+
+// WriteTo writes every metric in the Prometheus text format sorted by name
+func (registry *Registry) WriteTo(writer io.Writer) (int64, error) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	names := make([]string, 0, len(registry.families))
+	for name := range registry.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var output strings.Builder
+	for _, name := range names {
+		metric := registry.families[name]
+		if metric.help != "" {
+			fmt.Fprintf(&output, "# HELP %s %s\n", name, metric.help)
+		}
+		fmt.Fprintf(&output, "# TYPE %s %s\n", name, metric.kind)
+		values := make(map[string]float64, len(metric.values)+len(metric.gauges))
+		for key, value := range metric.values {
+			values[key] = value
+		}
+		for key, gauge := range metric.gauges {
+			values[key] = gauge()
+		}
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&output, "%s%s %v\n", name, key, values[key])
+		}
+	}
+	written, err := io.WriteString(writer, output.String())
+	return int64(written), err
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (registry *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		registry.WriteTo(writer)
+	})
+}
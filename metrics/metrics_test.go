@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Check that metrics are written in the Prometheus text format
+func TestWriteTo(t *testing.T) {
+	registry := NewRegistry()
+	registry.Describe("angrytock_messages_sent_total", "Messages sent to slack")
+	registry.Inc("angrytock_messages_sent_total", "kind", "dm")
+	registry.Inc("angrytock_messages_sent_total", "kind", "dm")
+	registry.Inc("angrytock_messages_sent_total", "kind", "channel")
+	registry.Observe("angrytock_tock_request_duration_seconds", 0.5)
+	registry.Observe("angrytock_tock_request_duration_seconds", 1.5)
+	registry.GaugeFunc("angrytock_users_mapped", func() float64 { return 42 })
+
+	var output strings.Builder
+	registry.WriteTo(&output)
+	expected := `# HELP angrytock_messages_sent_total Messages sent to slack
+# TYPE angrytock_messages_sent_total counter
+angrytock_messages_sent_total{kind="channel"} 1
+angrytock_messages_sent_total{kind="dm"} 2
+# TYPE angrytock_tock_request_duration_seconds summary
+angrytock_tock_request_duration_seconds_count 2
+angrytock_tock_request_duration_seconds_sum 2
+# TYPE angrytock_users_mapped gauge
+angrytock_users_mapped 42
+`
+	if output.String() != expected {
+		t.Error(output.String())
+	}
+}
+
+// Check that label values are escaped
+func TestLabelString(t *testing.T) {
+	labels := labelString([]string{"cache", `say "hi"`})
+	if labels != `{cache="say \"hi\""}` {
+		t.Error(labels)
+	}
+}
+
+// Check that the handler serves the metrics
+func TestHandler(t *testing.T) {
+	registry := NewRegistry()
+	registry.Inc("angrytock_test_total")
+	recorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(recorder.Body.String(), "angrytock_test_total 1") {
+		t.Error(recorder.Body.String())
+	}
+}
@@ -0,0 +1,45 @@
+package safeDict
+
+// channelDict is the previous SafeDict implementation, which serialized
+// every operation through a single goroutine. It is kept for benchmarks.
+type channelDict struct {
+	storage       map[string]string
+	readChannel   chan string
+	updateChannel chan [2]string
+	done          chan struct{}
+}
+
+func initChannelDict() *channelDict {
+	dict := &channelDict{
+		make(map[string]string),
+		make(chan string),
+		make(chan [2]string),
+		make(chan struct{}),
+	}
+	go func() {
+		for {
+			select {
+			case key := <-dict.readChannel:
+				dict.readChannel <- dict.storage[key]
+			case keyValuePair := <-dict.updateChannel:
+				dict.storage[keyValuePair[0]] = keyValuePair[1]
+			case <-dict.done:
+				return
+			}
+		}
+	}()
+	return dict
+}
+
+func (dict *channelDict) Get(key string) string {
+	dict.readChannel <- key
+	return <-dict.readChannel
+}
+
+func (dict *channelDict) Update(key string, value string) {
+	dict.updateChannel <- [2]string{key, value}
+}
+
+func (dict *channelDict) Close() {
+	close(dict.done)
+}
@@ -0,0 +1,60 @@
+package safeDict
+
+import "time"
+
+// SetWithTTL sets a key to a specific value that expires after ttl. Setting
+// a key again extends its expiration.
+func (dict *SafeDict[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	dict.mutex.Lock()
+	defer dict.mutex.Unlock()
+	dict.storage[key] = value
+	dict.expires[key] = time.Now().Add(ttl)
+}
+
+// ExpiresAt returns when a key expires. The second value is false if the
+// key is missing or never expires.
+func (dict *SafeDict[K, V]) ExpiresAt(key K) (time.Time, bool) {
+	dict.mutex.RLock()
+	defer dict.mutex.RUnlock()
+	expiration, ok := dict.expires[key]
+	if !ok || dict.expired(key, time.Now()) {
+		return time.Time{}, false
+	}
+	return expiration, true
+}
+
+// OnExpire sets a callback that the sweeper calls for every expired entry
+func (dict *SafeDict[K, V]) OnExpire(onExpire func(key K, value V)) {
+	dict.mutex.Lock()
+	defer dict.mutex.Unlock()
+	dict.onExpire = onExpire
+}
+
+// expired checks if a key has expired. The caller must hold the mutex.
+func (dict *SafeDict[K, V]) expired(key K, now time.Time) bool {
+	expiration, ok := dict.expires[key]
+	return ok && !now.Before(expiration)
+}
+
+// sweep removes the entries that expired before now and calls the
+// expiration callback for them
+func (dict *SafeDict[K, V]) sweep(now time.Time) {
+	expired := make(map[K]V)
+	dict.mutex.Lock()
+	for key := range dict.expires {
+		if dict.expired(key, now) {
+			expired[key] = dict.storage[key]
+			delete(dict.storage, key)
+			delete(dict.expires, key)
+		}
+	}
+	onExpire := dict.onExpire
+	dict.mutex.Unlock()
+	// Call back without the lock so the callback can use the dictionary
+	if onExpire == nil {
+		return
+	}
+	for key, value := range expired {
+		onExpire(key, value)
+	}
+}
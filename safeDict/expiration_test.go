@@ -0,0 +1,64 @@
+package safeDict
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// Check that entries with a TTL are missing once they expire
+func TestSetWithTTL(t *testing.T) {
+	dict := InitSafeDict[string, string]()
+	defer dict.Close()
+	dict.SetWithTTL("short", "value", time.Millisecond)
+	dict.SetWithTTL("long", "value", time.Hour)
+	dict.Update("forever", "value")
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := dict.GetOK("short"); ok {
+		t.Error("expected short to expire")
+	}
+	if dict.Get("long") != "value" || dict.Len() != 2 || len(dict.Keys()) != 2 {
+		t.Error(dict.Snapshot())
+	}
+	if _, ok := dict.ExpiresAt("forever"); ok {
+		t.Error("expected forever to never expire")
+	}
+	// Updating removes the ttl
+	dict.Update("short", "again")
+	if dict.Get("short") != "again" {
+		t.Error(dict.Snapshot())
+	}
+}
+
+// Check that setting a key again extends its expiration
+func TestSetWithTTLExtends(t *testing.T) {
+	dict := InitSafeDict[string, string]()
+	dict.SetWithTTL("key", "value", time.Minute)
+	first, _ := dict.ExpiresAt("key")
+	dict.SetWithTTL("key", "value", time.Hour)
+	second, ok := dict.ExpiresAt("key")
+	if !ok || !second.After(first) {
+		t.Error(first, second)
+	}
+}
+
+// Check that the sweeper removes expired entries and calls back
+func TestSweeper(t *testing.T) {
+	dict := InitSafeDict[string, string]()
+	var mutex sync.Mutex
+	var expiredKeys []string
+	dict.OnExpire(func(key string, value string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		expiredKeys = append(expiredKeys, key)
+	})
+	dict.SetWithTTL("key", "value", time.Millisecond)
+	dict.StartSweeper(time.Millisecond)
+	defer dict.Close()
+	time.Sleep(20 * time.Millisecond)
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(expiredKeys) != 1 || expiredKeys[0] != "key" {
+		t.Error(expiredKeys)
+	}
+}
@@ -1,22 +1,120 @@
 package safeDict
 
-// Get returns the value given a specific key
-func (dict *SafeDict) Get(key string) string {
-	dict.readChannel <- key
-	return <-dict.readChannel
+import "time"
+
+// Get returns the value given a specific key or the zero value if the key
+// is missing or expired
+func (dict *SafeDict[K, V]) Get(key K) V {
+	value, _ := dict.GetOK(key)
+	return value
+}
+
+// GetOK returns the value given a specific key and if the key was found
+func (dict *SafeDict[K, V]) GetOK(key K) (V, bool) {
+	dict.mutex.RLock()
+	defer dict.mutex.RUnlock()
+	value, ok := dict.storage[key]
+	if !ok || dict.expired(key, time.Now()) {
+		var zero V
+		return zero, false
+	}
+	return value, true
 }
 
-// Update sets a key to a specific value
-func (dict *SafeDict) Update(key string, value string) {
-	dict.updateChannel <- &keyValue{key: key, value: value}
+// Update sets a key to a specific value that never expires
+func (dict *SafeDict[K, V]) Update(key K, value V) {
+	dict.mutex.Lock()
+	defer dict.mutex.Unlock()
+	dict.storage[key] = value
+	delete(dict.expires, key)
+}
+
+// Compute changes the value of a key while holding the lock, so no other
+// change to the key can happen in between. computeFunc gets the current value
+// and if the key was found and returns the new value, which never expires,
+// and false to delete the key instead.
+func (dict *SafeDict[K, V]) Compute(key K, computeFunc func(value V, ok bool) (V, bool)) {
+	dict.mutex.Lock()
+	defer dict.mutex.Unlock()
+	value, ok := dict.storage[key]
+	if ok && dict.expired(key, time.Now()) {
+		var zero V
+		value, ok = zero, false
+	}
+	value, keep := computeFunc(value, ok)
+	delete(dict.expires, key)
+	if !keep {
+		delete(dict.storage, key)
+		return
+	}
+	dict.storage[key] = value
 }
 
 // Delete removes a key-value pair given a key
-func (dict *SafeDict) Delete(key string) {
-	dict.deleteChannel <- key
+func (dict *SafeDict[K, V]) Delete(key K) {
+	dict.mutex.Lock()
+	defer dict.mutex.Unlock()
+	delete(dict.storage, key)
+	delete(dict.expires, key)
+}
+
+// Replace replaces the internal hashmap with a copy of newDict. None of the
+// new entries expire.
+func (dict *SafeDict[K, V]) Replace(newDict map[K]V) {
+	storage := make(map[K]V, len(newDict))
+	for key, value := range newDict {
+		storage[key] = value
+	}
+	dict.mutex.Lock()
+	defer dict.mutex.Unlock()
+	dict.storage = storage
+	dict.expires = make(map[K]time.Time)
+}
+
+// Keys returns a list of all the keys that haven't expired
+func (dict *SafeDict[K, V]) Keys() []K {
+	snapshot := dict.Snapshot()
+	keys := make([]K, 0, len(snapshot))
+	for key := range snapshot {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Len returns the number of key-value pairs that haven't expired
+func (dict *SafeDict[K, V]) Len() int {
+	dict.mutex.RLock()
+	defer dict.mutex.RUnlock()
+	now := time.Now()
+	length := len(dict.storage)
+	for key := range dict.expires {
+		if dict.expired(key, now) {
+			length--
+		}
+	}
+	return length
+}
+
+// Snapshot returns a copy of the internal hashmap without expired entries
+func (dict *SafeDict[K, V]) Snapshot() map[K]V {
+	dict.mutex.RLock()
+	defer dict.mutex.RUnlock()
+	now := time.Now()
+	snapshot := make(map[K]V, len(dict.storage))
+	for key, value := range dict.storage {
+		if !dict.expired(key, now) {
+			snapshot[key] = value
+		}
+	}
+	return snapshot
 }
 
-// Replace replaces the internal hashmap
-func (dict *SafeDict) Replace(newDict map[string]string) {
-	dict.replaceChannel <- newDict
+// Range calls applyFunc for every key-value pair until it returns false.
+// It ranges over a snapshot so applyFunc can modify the dictionary.
+func (dict *SafeDict[K, V]) Range(applyFunc func(key K, value V) bool) {
+	for key, value := range dict.Snapshot() {
+		if !applyFunc(key, value) {
+			return
+		}
+	}
 }
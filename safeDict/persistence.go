@@ -0,0 +1,118 @@
+package safeDict
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// entry is the JSON representation of one key-value pair in a snapshot
+type entry[K comparable, V any] struct {
+	Key     K          `json:"key"`
+	Value   V          `json:"value"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// MarshalJSON encodes the entries that haven't expired as a JSON list
+func (dict *SafeDict[K, V]) MarshalJSON() ([]byte, error) {
+	dict.mutex.RLock()
+	now := time.Now()
+	entries := make([]entry[K, V], 0, len(dict.storage))
+	for key, value := range dict.storage {
+		if dict.expired(key, now) {
+			continue
+		}
+		item := entry[K, V]{Key: key, Value: value}
+		if expiration, ok := dict.expires[key]; ok {
+			item.Expires = &expiration
+		}
+		entries = append(entries, item)
+	}
+	dict.mutex.RUnlock()
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON replaces the contents of the dictionary with a JSON list of
+// entries. Entries that have already expired are dropped.
+func (dict *SafeDict[K, V]) UnmarshalJSON(data []byte) error {
+	var entries []entry[K, V]
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	now := time.Now()
+	storage := make(map[K]V, len(entries))
+	expires := make(map[K]time.Time)
+	for _, item := range entries {
+		if item.Expires != nil {
+			if !now.Before(*item.Expires) {
+				continue
+			}
+			expires[item.Key] = *item.Expires
+		}
+		storage[item.Key] = item.Value
+	}
+	dict.mutex.Lock()
+	defer dict.mutex.Unlock()
+	dict.storage = storage
+	dict.expires = expires
+	return nil
+}
+
+// SaveFile writes a snapshot of the dictionary to path. The snapshot is
+// written to a temporary file first and moved into place so that readers
+// never see a partial file.
+func (dict *SafeDict[K, V]) SaveFile(path string) error {
+	data, err := dict.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	// Clean up the temporary file if it was not moved into place
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// LoadFile replaces the contents of the dictionary with a snapshot written
+// by SaveFile
+func (dict *SafeDict[K, V]) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return dict.UnmarshalJSON(data)
+}
+
+// StartSnapshots saves the dictionary to path every interval until the
+// dictionary is closed
+func (dict *SafeDict[K, V]) StartSnapshots(path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := dict.SaveFile(path); err != nil {
+					log.Printf("Unable to save %s: %s", path, err)
+				}
+			case <-dict.done:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,70 @@
+package safeDict
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Check that a saved dictionary can be loaded with its expirations
+func TestSaveAndLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dict.json")
+	dict := InitSafeDict[string, string]()
+	dict.Update("email@gsa.gov", "U1234")
+	dict.SetWithTTL("U5678", "other@gsa.gov", time.Hour)
+	dict.SetWithTTL("expired", "value", time.Nanosecond)
+	if err := dict.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := InitSafeDict[string, string]()
+	loaded.Update("stale", "value")
+	if err := loaded.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Len() != 2 || loaded.Get("email@gsa.gov") != "U1234" || loaded.Get("stale") != "" {
+		t.Error(loaded.Snapshot())
+	}
+	if _, ok := loaded.ExpiresAt("U5678"); !ok {
+		t.Error("expected U5678 to keep its expiration")
+	}
+	if _, ok := loaded.ExpiresAt("email@gsa.gov"); ok {
+		t.Error("expected email@gsa.gov to never expire")
+	}
+}
+
+// Check that saving replaces the file and leaves no temporary files behind
+func TestSaveFileReplaces(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dict.json")
+	dict := InitSafeDict[string, int]()
+	dict.Update("count", 1)
+	if err := dict.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	dict.Update("count", 2)
+	if err := dict.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Error(files)
+	}
+	loaded := InitSafeDict[string, int]()
+	if err := loaded.LoadFile(path); err != nil || loaded.Get("count") != 2 {
+		t.Error(err, loaded.Snapshot())
+	}
+}
+
+// Check that loading a missing file fails and keeps the contents
+func TestLoadMissingFile(t *testing.T) {
+	dict := InitSafeDict[string, string]()
+	dict.Update("key", "value")
+	if err := dict.LoadFile(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Error(err)
+	}
+	if dict.Get("key") != "value" {
+		t.Error(dict.Snapshot())
+	}
+}
@@ -0,0 +1,154 @@
+package safeDict
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// Check the basic operations on a SafeDict
+func TestSafeDict(t *testing.T) {
+	dict := InitSafeDict[string, int]()
+	defer dict.Close()
+	dict.Update("one", 1)
+	dict.Update("two", 2)
+	if value, ok := dict.GetOK("one"); !ok || value != 1 {
+		t.Error(value, ok)
+	}
+	if value, ok := dict.GetOK("three"); ok || value != 0 {
+		t.Error(value, ok)
+	}
+	dict.Delete("one")
+	if dict.Get("one") != 0 || dict.Len() != 1 {
+		t.Error(dict.Snapshot())
+	}
+	newDict := map[string]int{"a": 1, "b": 2}
+	dict.Replace(newDict)
+	newDict["c"] = 3
+	keys := dict.Keys()
+	sort.Strings(keys)
+	if fmt.Sprint(keys) != "[a b]" {
+		t.Error(keys)
+	}
+}
+
+// Check that Compute changes, adds and deletes keys from their current value
+func TestSafeDictCompute(t *testing.T) {
+	dict := InitSafeDict[string, int]()
+	defer dict.Close()
+	dict.Update("one", 1)
+	dict.Compute("one", func(value int, ok bool) (int, bool) {
+		return value + 1, ok
+	})
+	dict.Compute("new", func(value int, ok bool) (int, bool) {
+		if ok {
+			t.Error("missing keys should not be found", value)
+		}
+		return 5, true
+	})
+	if dict.Get("one") != 2 || dict.Get("new") != 5 {
+		t.Error(dict.Snapshot())
+	}
+	dict.Compute("one", func(value int, ok bool) (int, bool) {
+		return 0, false
+	})
+	if _, ok := dict.GetOK("one"); ok || dict.Len() != 1 {
+		t.Error(dict.Snapshot())
+	}
+
+	// Concurrent changes to the same key are never lost
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				dict.Compute("count", func(value int, ok bool) (int, bool) {
+					return value + 1, true
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if dict.Get("count") != 1000 {
+		t.Error(dict.Get("count"))
+	}
+}
+
+// Check that Range works on a snapshot and stops early
+func TestSafeDictRange(t *testing.T) {
+	dict := InitSafeDict[int, int]()
+	for i := 0; i < 10; i++ {
+		dict.Update(i, i)
+	}
+	visited := 0
+	dict.Range(func(key int, value int) bool {
+		dict.Delete(key)
+		visited++
+		return visited < 5
+	})
+	if visited != 5 || dict.Len() != 5 {
+		t.Error(visited, dict.Len())
+	}
+}
+
+// Check that concurrent reads and writes are safe and Close is idempotent
+func TestSafeDictConcurrency(t *testing.T) {
+	dict := InitSafeDict[int, int]()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				dict.Update(i*100+j, j)
+				dict.Get(j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if dict.Len() != 1000 {
+		t.Error(dict.Len())
+	}
+	dict.Close()
+	DestorySaftDict(dict)
+}
+
+func BenchmarkSafeDictGet(b *testing.B) {
+	dict := InitSafeDict[string, string]()
+	defer dict.Close()
+	dict.Update("user@gsa.gov", "U1234")
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			dict.Get("user@gsa.gov")
+		}
+	})
+}
+
+func BenchmarkChannelDictGet(b *testing.B) {
+	dict := initChannelDict()
+	defer dict.Close()
+	dict.Update("user@gsa.gov", "U1234")
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			dict.Get("user@gsa.gov")
+		}
+	})
+}
+
+func BenchmarkSafeDictUpdate(b *testing.B) {
+	dict := InitSafeDict[string, string]()
+	defer dict.Close()
+	for i := 0; i < b.N; i++ {
+		dict.Update("user@gsa.gov", "U1234")
+	}
+}
+
+func BenchmarkChannelDictUpdate(b *testing.B) {
+	dict := initChannelDict()
+	defer dict.Close()
+	for i := 0; i < b.N; i++ {
+		dict.Update("user@gsa.gov", "U1234")
+	}
+}
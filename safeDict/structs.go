@@ -1,59 +1,65 @@
 // Package safeDict contains the structs and methods for creates a thread saft map
 package safeDict
 
-// keyValue struct stores the a key-value pair for use in a channel
-type keyValue struct {
-	key   string
-	value string
-}
+import (
+	"sync"
+	"time"
+)
 
-// SafeDict struct is a data type that contains a map along with channels
-// that allow users to preform CRUD operations. The main difference between
-// the SafeDict and a map is that all operations are serialized and thus thread safe.
-type SafeDict struct {
-	storage        map[string]string
-	readChannel    chan string
-	updateChannel  chan *keyValue
-	deleteChannel  chan string
-	replaceChannel chan map[string]string
+// SafeDict struct is a map guarded by a read-write mutex. The main
+// difference between the SafeDict and a map is that all operations are
+// thread safe and reads don't block each other. Entries can be given a time
+// to live, after which they are treated as missing and removed by a sweeper.
+type SafeDict[K comparable, V any] struct {
+	storage   map[K]V
+	expires   map[K]time.Time
+	onExpire  func(key K, value V)
+	mutex     sync.RWMutex
+	done      chan struct{}
+	closeOnce sync.Once
+	sweepOnce sync.Once
 }
 
-// newSafeDict initalizes a new SafeDict and opens all the channels.
-func newSafeDict() *SafeDict {
-	return &SafeDict{
-		make(map[string]string),
-		make(chan string),
-		make(chan *keyValue),
-		make(chan string),
-		make(chan map[string]string),
+// InitSafeDict initalizes a new, empty SafeDict
+func InitSafeDict[K comparable, V any]() *SafeDict[K, V] {
+	return &SafeDict[K, V]{
+		storage: make(map[K]V),
+		expires: make(map[K]time.Time),
+		done:    make(chan struct{}),
 	}
 }
 
-// InitSafeDict initalizes a new SafeDict and creates for loop that listens for
-// new operations.
-func InitSafeDict() *SafeDict {
-	safeDict := newSafeDict()
-	go func() {
-		for {
-			select {
-			case key := <-safeDict.readChannel:
-				safeDict.readChannel <- safeDict.storage[key]
-			case keyValuePair := <-safeDict.updateChannel:
-				safeDict.storage[keyValuePair.key] = keyValuePair.value
-			case key := <-safeDict.deleteChannel:
-				delete(safeDict.storage, key)
-			case newDict := <-safeDict.replaceChannel:
-				safeDict.storage = newDict
+// StartSweeper starts a goroutine that removes expired entries every
+// interval and calls the expiration callback for them. Only the first call
+// starts a sweeper and Close stops it.
+func (dict *SafeDict[K, V]) StartSweeper(interval time.Duration) {
+	dict.sweepOnce.Do(func() {
+		ticker := time.NewTicker(interval)
+		go func() {
+			defer ticker.Stop()
+			for {
+				select {
+				case now := <-ticker.C:
+					dict.sweep(now)
+				case <-dict.done:
+					return
+				}
 			}
-		}
-	}()
-	return safeDict
+		}()
+	})
+}
+
+// Close stops the sweeper. The contents can still be read after closing.
+// It is safe to call Close more than once.
+func (dict *SafeDict[K, V]) Close() {
+	dict.closeOnce.Do(func() {
+		close(dict.done)
+	})
 }
 
-// DestorySaftDict closes all the dictionary channels
-func DestorySaftDict(dict *SafeDict) {
-	close(dict.readChannel)
-	close(dict.updateChannel)
-	close(dict.deleteChannel)
-	close(dict.replaceChannel)
+// DestorySaftDict closes the dictionary.
+//
+// Deprecated: use Close.
+func DestorySaftDict[K comparable, V any](dict *SafeDict[K, V]) {
+	dict.Close()
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/18F/angrytock/metrics"
 	"github.com/cloudfoundry-community/go-cfenv"
 	"github.com/nlopes/slack"
 )
@@ -26,6 +27,27 @@ func InitSlack() *Slack {
 	return &Slack{rtm}
 }
 
+// recordSend counts a message sent to slack by kind, e.g. `dm`, and
+// whether it failed
+func recordSend(kind string, err error) {
+	if err != nil {
+		metrics.Default.Inc("angrytock_slack_errors_total", "kind", kind)
+		return
+	}
+	metrics.Default.Inc("angrytock_messages_sent_total", "kind", kind)
+}
+
+// SendToChannel sends a message to a channel over the real time connection
+func (api *Slack) SendToChannel(channelID string, message string) {
+	api.SendMessage(api.NewOutgoingMessage(message, channelID))
+	recordSend("rtm", nil)
+}
+
+// Events returns the events of the real time connection
+func (api *Slack) Events() <-chan slack.RTMEvent {
+	return api.IncomingEvents
+}
+
 // FetchSlackUsers fetches a list of slack users and saves thier user ids by
 // this method could use the GetInfo()
 func (api *Slack) FetchSlackUsers() []slack.User {
@@ -46,9 +68,56 @@ func (api *Slack) MessageUser(user string, message string) {
 	_, _, channelID, err := api.Client.OpenIMChannel(user)
 	if err != nil {
 		log.Println("Unable to open channel")
+		recordSend("dm", err)
+		return
 	}
 	// Can insert images an other things here
-	postParams := slack.PostMessageParameters{}
-	api.Client.PostMessage(channelID, message, postParams)
+	_, _, err = api.Client.PostMessage(channelID, slack.MsgOptionText(message, false))
+	recordSend("dm", err)
+
+}
+
+// MessageChannel posts a message to a channel as the bot
+func (api *Slack) MessageChannel(channelID string, message string) {
+	_, _, err := api.Client.PostMessage(
+		channelID,
+		slack.MsgOptionText(message, false),
+		slack.MsgOptionAsUser(true),
+	)
+	recordSend("channel", err)
+	if err != nil {
+		log.Printf("Unable to post to channel %s: %s", channelID, err)
+	}
+}
+
+// ReactToMessage adds an emoji reaction, e.g. `alarm_clock`, to a message
+func (api *Slack) ReactToMessage(channelID string, timestamp string, emoji string) {
+	err := api.Client.AddReaction(emoji, slack.NewRefToMessage(channelID, timestamp))
+	recordSend("reaction", err)
+	if err != nil {
+		log.Printf("Unable to react to message in %s: %s", channelID, err)
+	}
+}
 
+// ReplyInThread posts a message as the bot in the thread of a message
+func (api *Slack) ReplyInThread(channelID string, threadTimestamp string, message string) {
+	_, _, err := api.Client.PostMessage(
+		channelID,
+		slack.MsgOptionText(message, false),
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionTS(threadTimestamp),
+	)
+	recordSend("thread", err)
+	if err != nil {
+		log.Printf("Unable to reply in thread in %s: %s", channelID, err)
+	}
+}
+
+// MessageUserEphemeral posts a message in a channel that only one user can see
+func (api *Slack) MessageUserEphemeral(channelID string, user string, message string) {
+	_, err := api.Client.PostEphemeral(channelID, user, slack.MsgOptionText(message, false))
+	recordSend("ephemeral", err)
+	if err != nil {
+		log.Printf("Unable to post ephemeral message in %s: %s", channelID, err)
+	}
 }
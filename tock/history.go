@@ -0,0 +1,95 @@
+package tockPackage
+
+import (
+	"sort"
+	"strings"
+)
+
+// PeriodHistory holds the users who were late for an ended reporting period
+type PeriodHistory struct {
+	Period    ReportingPeriod
+	LateUsers []User
+}
+
+// UnitRate is the share of on time timecards for a tock unit
+type UnitRate struct {
+	Unit    string
+	Members int
+	OnTime  float64
+}
+
+// FetchHistory collects the late users for up to count of the reporting
+// periods that have ended, most recent first. An error is returned if the
+// late users of any of the periods can't be fetched.
+func (tock *Tock) FetchHistory(count int) ([]PeriodHistory, error) {
+	recent, _ := tock.FetchReportingPeriods().Timeline(count)
+	history := make([]PeriodHistory, 0, len(recent))
+	for _, period := range recent {
+		periodHistory := PeriodHistory{Period: period}
+		err := tock.PeriodUserApplier(period.StartDate, func(user User) {
+			periodHistory.LateUsers = append(periodHistory.LateUsers, user)
+		})
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, periodHistory)
+	}
+	return history, nil
+}
+
+// Streak counts the consecutive periods, starting with the most recent, in
+// which isUser did not match any late user
+func Streak(history []PeriodHistory, isUser func(user User) bool) int {
+	streak := 0
+	for _, periodHistory := range history {
+		for _, user := range periodHistory.LateUsers {
+			if isUser(user) {
+				return streak
+			}
+		}
+		streak++
+	}
+	return streak
+}
+
+// UnitOnTimeRates calculates the percent of on time timecards for each unit
+// of current employees over the history, best first
+func UnitOnTimeRates(history []PeriodHistory, userData map[string]*UserData) []UnitRate {
+	members := make(map[string]int)
+	units := make(map[string]string)
+	for _, profile := range userData {
+		if profile.CurrentEmployee && profile.Unit != "" {
+			unitKey := strings.ToLower(profile.Unit)
+			members[unitKey]++
+			units[unitKey] = profile.Unit
+		}
+	}
+	late := make(map[string]int)
+	for _, periodHistory := range history {
+		for _, user := range periodHistory.LateUsers {
+			profile := userData[user.Username]
+			if profile != nil && profile.CurrentEmployee {
+				late[strings.ToLower(profile.Unit)]++
+			}
+		}
+	}
+	var rates []UnitRate
+	for unitKey, count := range members {
+		timecards := count * len(history)
+		if timecards == 0 {
+			continue
+		}
+		rates = append(rates, UnitRate{
+			Unit:    units[unitKey],
+			Members: count,
+			OnTime:  100 * float64(timecards-late[unitKey]) / float64(timecards),
+		})
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].OnTime == rates[j].OnTime {
+			return rates[i].Unit < rates[j].Unit
+		}
+		return rates[i].OnTime > rates[j].OnTime
+	})
+	return rates
+}
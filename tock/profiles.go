@@ -0,0 +1,80 @@
+package tockPackage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// UserData is a struct representation of the user_data JSON object from tock
+// and holds the organizational details of a user
+type UserData struct {
+	Username     string `json:"user"`
+	Unit         string `json:"unit"`
+	Organization string `json:"organization"`
+	// Supervisor is the email of the user's supervisor
+	Supervisor      string `json:"supervisor"`
+	CurrentEmployee bool   `json:"current_employee"`
+}
+
+// FetchUserData collects the organizational data for every tock user keyed
+// by username. An error is returned if the data can't be fetched or read.
+func (tock *Tock) FetchUserData() (map[string]*UserData, error) {
+	var data []*UserData
+	URL := fmt.Sprintf("%s/api/user_data.json", tock.TockURL)
+	body := tock.DataFetcher.FetchData(URL)
+	if body == nil {
+		err := fmt.Errorf("no response from %s", URL)
+		log.Print(err)
+		return nil, err
+	}
+	err := json.Unmarshal(body, &data)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+	userData := make(map[string]*UserData)
+	for _, profile := range data {
+		userData[profile.Username] = profile
+	}
+	return userData, nil
+}
+
+// EmployeeURL returns the link to a user's page in tock
+func (tock *Tock) EmployeeURL(username string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(tock.UserTockURL, "/"), username)
+}
+
+// InUnit checks if a user belongs to a unit, ignoring case
+func (user User) InUnit(unit string) bool {
+	return user.Profile != nil && strings.EqualFold(user.Profile.Unit, unit)
+}
+
+// ProfiledUserApplier applies a anonymous function to the late tock users of
+// the reporting period starting on timePeriod with their profiles attached.
+// Nothing is applied if the profiles can't be fetched.
+func (tock *Tock) ProfiledUserApplier(timePeriod string, applyFunc func(user User)) error {
+	userData, err := tock.FetchUserData()
+	if err != nil {
+		return err
+	}
+	return tock.PeriodUserApplier(timePeriod, func(user User) {
+		user.Profile = userData[user.Username]
+		applyFunc(user)
+	})
+}
+
+// UnitUserApplier applies a anonymous function to the late tock users in a
+// unit for the current reporting period
+func (tock *Tock) UnitUserApplier(unit string, applyFunc func(user User)) error {
+	timePeriod := tock.fetchReportingPeriod()
+	if timePeriod == "" {
+		return ErrNoReportingPeriod
+	}
+	return tock.ProfiledUserApplier(timePeriod, func(user User) {
+		if user.InUnit(unit) {
+			applyFunc(user)
+		}
+	})
+}
@@ -2,8 +2,10 @@ package tockPackage
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/18F/angrytock/helpers"
@@ -17,6 +19,8 @@ type User struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Email     string `json:"email"`
+	// Profile holds the user's tock organizational data when it was requested
+	Profile *UserData `json:"-"`
 }
 
 // ReportingPeriod is a struct representation of the reporting_period JSON object from tock
@@ -49,6 +53,9 @@ type ReportingPeriodAuditDetails struct {
 	Users []User `json:"results"`
 }
 
+// ErrNoReportingPeriod is returned when tock doesn't list a reporting period
+var ErrNoReportingPeriod = errors.New("unable to find the current reporting period")
+
 // Tock struct contains the audit endpoint and methods associated with Tock
 type Tock struct {
 	// Get Audit endpoint
@@ -78,9 +85,9 @@ func InitTock() *Tock {
 	return &Tock{tockURL, userTockURL, auditEndpoint, dataFetcher}
 }
 
-// fetchCurrentReportingPeriod gets the latest reporting time period that
-// has happend
-func fetchCurrentReportingPeriod(data *ReportingPeriodAuditList) string {
+// fetchCurrentReportingPeriodIndex gets the index of the latest reporting
+// time period that has happend
+func fetchCurrentReportingPeriodIndex(data *ReportingPeriodAuditList) int {
 	currentPeriodIndex := 0
 	for idx, period := range data.ReportingPeriods {
 		endDate, _ := time.Parse("2006-01-02", period.EndDate)
@@ -89,11 +96,59 @@ func fetchCurrentReportingPeriod(data *ReportingPeriodAuditList) string {
 			break
 		}
 	}
-	return data.ReportingPeriods[currentPeriodIndex].StartDate
+	return currentPeriodIndex
 }
 
-// fetchReportingPeriod collects the current reporting period
-func (tock *Tock) fetchReportingPeriod() string {
+// fetchCurrentReportingPeriod gets the latest reporting time period that
+// has happend, or an empty string if tock didn't list any periods
+func fetchCurrentReportingPeriod(data *ReportingPeriodAuditList) string {
+	if len(data.ReportingPeriods) == 0 {
+		return ""
+	}
+	return data.ReportingPeriods[fetchCurrentReportingPeriodIndex(data)].StartDate
+}
+
+// Timeline returns up to count of the reporting periods that have ended,
+// most recent first, and the next period to end if tock lists one
+func (data *ReportingPeriodAuditList) Timeline(count int) ([]ReportingPeriod, *ReportingPeriod) {
+	if len(data.ReportingPeriods) == 0 {
+		return nil, nil
+	}
+	currentPeriodIndex := fetchCurrentReportingPeriodIndex(data)
+	lastIndex := currentPeriodIndex + count
+	if lastIndex > len(data.ReportingPeriods) {
+		lastIndex = len(data.ReportingPeriods)
+	}
+	recent := data.ReportingPeriods[currentPeriodIndex:lastIndex]
+	if currentPeriodIndex == 0 {
+		return recent, nil
+	}
+	return recent, &data.ReportingPeriods[currentPeriodIndex-1]
+}
+
+// HoursRequirement describes the hours a user needs to log for the period
+func (period ReportingPeriod) HoursRequirement() string {
+	if period.ExactWorkingHours > 0 {
+		return fmt.Sprintf("%d hours required", period.ExactWorkingHours)
+	}
+	if period.MaxWorkingHours > 0 {
+		return fmt.Sprintf("%d to %d hours required", period.MinWorkingHours, period.MaxWorkingHours)
+	}
+	return fmt.Sprintf("at least %d hours required", period.MinWorkingHours)
+}
+
+// DateRange returns the start and end dates of the period
+func (period ReportingPeriod) DateRange() string {
+	return fmt.Sprintf("%s to %s", period.StartDate, period.EndDate)
+}
+
+// TimecardURL returns the link to the timecard for a reporting period
+func (tock *Tock) TimecardURL(period ReportingPeriod) string {
+	return fmt.Sprintf("%s/reporting_period/%s/", strings.TrimSuffix(tock.TockURL, "/"), period.StartDate)
+}
+
+// FetchReportingPeriods collects the list of reporting periods, most recent first
+func (tock *Tock) FetchReportingPeriods() *ReportingPeriodAuditList {
 	var data ReportingPeriodAuditList
 	URL := fmt.Sprintf("%s.json", tock.AuditEndpoint)
 	body := tock.DataFetcher.FetchData(URL)
@@ -101,51 +156,111 @@ func (tock *Tock) fetchReportingPeriod() string {
 	if err != nil {
 		log.Print(err)
 	}
-	return fetchCurrentReportingPeriod(&data)
+	return &data
+}
+
+// fetchReportingPeriod collects the current reporting period
+func (tock *Tock) fetchReportingPeriod() string {
+	return fetchCurrentReportingPeriod(tock.FetchReportingPeriods())
+}
+
+// Ping checks that the tock api can be reached
+func (tock *Tock) Ping() error {
+	return helpers.CheckURL(tock.AuditEndpoint, 10*time.Second)
+}
+
+// CurrentReportingPeriod returns the start date of the current reporting period
+func (tock *Tock) CurrentReportingPeriod() string {
+	return tock.fetchReportingPeriod()
+}
+
+// FetchCurrentAndPreviousPeriods returns the current reporting period and the
+// one before it. The previous period is nil if tock does not list one.
+func (tock *Tock) FetchCurrentAndPreviousPeriods() (*ReportingPeriod, *ReportingPeriod) {
+	data := tock.FetchReportingPeriods()
+	if len(data.ReportingPeriods) == 0 {
+		return nil, nil
+	}
+	currentPeriodIndex := fetchCurrentReportingPeriodIndex(data)
+	current := &data.ReportingPeriods[currentPeriodIndex]
+	if currentPeriodIndex+1 >= len(data.ReportingPeriods) {
+		return current, nil
+	}
+	return current, &data.ReportingPeriods[currentPeriodIndex+1]
 }
 
 // FetchTockUsers is a function for collecting all the users who have not
-// filled out thier time sheet for the current period
-func (tock *Tock) FetchTockUsers(endpoint string) *ReportingPeriodAuditDetails {
+// filled out thier time sheet for the current period. An error is returned
+// when tock can't be reached or its response can't be read.
+func (tock *Tock) FetchTockUsers(endpoint string) (*ReportingPeriodAuditDetails, error) {
 	var data ReportingPeriodAuditDetails
 	body := tock.DataFetcher.FetchData(endpoint)
+	if body == nil {
+		err := fmt.Errorf("no response from %s", endpoint)
+		log.Print(err)
+		return &data, err
+	}
 	err := json.Unmarshal(body, &data.Users)
 	if err != nil {
 		log.Print(err)
 	}
-	return &data
+	return &data, err
 }
 
 // TockUserGen returns a generator that returns a steram
 // of user data by paging through the api
-func (tock *Tock) TockUserGen() func() *ReportingPeriodAuditDetails {
-	timePeriod := tock.fetchReportingPeriod()
+func (tock *Tock) TockUserGen() func() (*ReportingPeriodAuditDetails, error) {
+	return tock.PeriodUserGen(tock.fetchReportingPeriod())
+}
+
+// PeriodUserGen returns a generator that pages through the late users of the
+// reporting period starting on timePeriod
+func (tock *Tock) PeriodUserGen(timePeriod string) func() (*ReportingPeriodAuditDetails, error) {
 	baseEndpoint := fmt.Sprintf("%s/%s.json", tock.AuditEndpoint, timePeriod)
 	currentPage := 1
 	newEndpoint := baseEndpoint + fmt.Sprintf("?page=%d", currentPage)
-	return func() *ReportingPeriodAuditDetails {
-		usersResponse := tock.FetchTockUsers(newEndpoint)
+	return func() (*ReportingPeriodAuditDetails, error) {
+		usersResponse, err := tock.FetchTockUsers(newEndpoint)
 		currentPage++
 		newEndpoint = baseEndpoint + fmt.Sprintf("?page=%d", currentPage)
-		return usersResponse
+		return usersResponse, err
 	}
 }
 
 // UserApplier loops through users and applies a anonymous function to a list
-// of late tock users
-func (tock *Tock) UserApplier(applyFunc func(user User)) {
-	// user Generator
-	userGen := tock.TockUserGen()
+// of late tock users. It returns an error if the current reporting period or
+// a page of users can't be fetched, in which case only some users may have
+// been applied.
+func (tock *Tock) UserApplier(applyFunc func(user User)) error {
+	timePeriod := tock.fetchReportingPeriod()
+	if timePeriod == "" {
+		return ErrNoReportingPeriod
+	}
+	return tock.PeriodUserApplier(timePeriod, applyFunc)
+}
+
+// PeriodUserApplier applies a anonymous function to the late tock users of
+// the reporting period starting on timePeriod. It returns an error if a page
+// of users can't be fetched.
+func (tock *Tock) PeriodUserApplier(timePeriod string, applyFunc func(user User)) error {
+	return applyToUsers(tock.PeriodUserGen(timePeriod), applyFunc)
+}
+
+// applyToUsers pulls every page from a user generator and applies applyFunc
+// to each user. It stops at the first page that can't be fetched.
+func applyToUsers(userGen func() (*ReportingPeriodAuditDetails, error), applyFunc func(user User)) error {
 	// get event indefinitely
 	for {
-		apiResponse := userGen()
+		apiResponse, err := userGen()
+		if err != nil {
+			return err
+		}
 		for _, user := range apiResponse.Users {
 			applyFunc(user)
 		}
 		// Break loop if there are no more urls
 		if apiResponse.NextURL == "" {
-			break
+			return nil
 		}
 	}
-
 }
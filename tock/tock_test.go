@@ -114,8 +114,160 @@ func TestFetchTockReportingPeriods(t *testing.T) {
 func TestFetchTockUsers(t *testing.T) {
 	reportingPeriod := tock.fetchReportingPeriod()
 	baseEndpoint := fmt.Sprintf("%s%s", tock.AuditEndpoint, reportingPeriod)
-	userData := tock.FetchTockUsers(baseEndpoint)
-	if len(userData.Users) != 2 {
+	userData, err := tock.FetchTockUsers(baseEndpoint)
+	if err != nil || len(userData.Users) != 2 {
 		t.Error(userData)
 	}
 }
+
+func failingFetcher(url string) []byte {
+	return nil
+}
+
+// Check that a failed request is an error instead of an empty list of users
+func TestPeriodUserApplierError(t *testing.T) {
+	failingTock := Tock{"TockURL", "UserTockURL", "AuditEndpoint", helpers.NewDataFetcher(failingFetcher)}
+	applied := 0
+	err := failingTock.PeriodUserApplier("2014-11-22", func(user User) {
+		applied++
+	})
+	if err == nil || applied != 0 {
+		t.Error(err, applied)
+	}
+}
+
+func periodListFetcher(url string) []byte {
+	return []byte(`[
+		{"start_date":"2014-11-22","end_date":"2014-11-28"},
+		{"start_date":"2014-11-15","end_date":"2014-11-21"}
+	]`)
+}
+
+// Check that the previous period is the one listed after the current period
+func TestFetchCurrentAndPreviousPeriods(t *testing.T) {
+	periodTock := Tock{"TockURL", "UserTockURL", "AuditEndpoint", helpers.NewDataFetcher(periodListFetcher)}
+	current, previous := periodTock.FetchCurrentAndPreviousPeriods()
+	if current == nil || current.StartDate != "2014-11-22" {
+		t.Error(current)
+	}
+	if previous == nil || previous.StartDate != "2014-11-15" {
+		t.Error(previous)
+	}
+}
+
+func userDataFetcher(url string) []byte {
+	return []byte(`[
+		{"user":"user.one","unit":"Engineering","supervisor":"boss.one@gsa.gov","current_employee":true},
+		{"user":"user.two","unit":"Design","supervisor":"boss.two@gsa.gov","current_employee":true}
+	]`)
+}
+
+// Check that user data is keyed by username and matched to units
+func TestFetchUserData(t *testing.T) {
+	profileTock := Tock{"TockURL", "UserTockURL", "AuditEndpoint", helpers.NewDataFetcher(userDataFetcher)}
+	userData, err := profileTock.FetchUserData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := User{Username: "user.one", Profile: userData["user.one"]}
+	if !user.InUnit("engineering") || user.InUnit("Design") {
+		t.Error(user.Profile)
+	}
+	if (User{Username: "user.three"}).InUnit("Engineering") {
+		t.Error("users without a profile should not be in a unit")
+	}
+}
+
+// Check that missing profiles are an error instead of users without units
+func TestFetchUserDataError(t *testing.T) {
+	failingTock := Tock{"TockURL", "UserTockURL", "AuditEndpoint", helpers.NewDataFetcher(failingFetcher)}
+	if userData, err := failingTock.FetchUserData(); err == nil || userData != nil {
+		t.Error(userData, err)
+	}
+	applied := 0
+	err := failingTock.ProfiledUserApplier("2014-11-22", func(user User) {
+		applied++
+	})
+	if err == nil || applied != 0 {
+		t.Error(err, applied)
+	}
+}
+
+// Check that the timeline splits ended periods from the next open period
+func TestReportingPeriodTimeline(t *testing.T) {
+	nextPeriod := ReportingPeriod{
+		StartDate: time.Now().Add(time.Hour * -24 * 2).Format("2006-01-02"),
+		EndDate:   time.Now().Add(time.Hour * 24 * 5).Format("2006-01-02"),
+	}
+	data := ReportingPeriodAuditList{
+		ReportingPeriods: []ReportingPeriod{
+			nextPeriod,
+			ReportingPeriod{StartDate: "2014-01-07", EndDate: "2014-01-12"},
+			ReportingPeriod{StartDate: "2014-01-01", EndDate: "2014-01-05"},
+		},
+	}
+	recent, next := data.Timeline(5)
+	if len(recent) != 2 || recent[0].StartDate != "2014-01-07" {
+		t.Error(recent)
+	}
+	if next == nil || next.StartDate != nextPeriod.StartDate {
+		t.Error(next)
+	}
+	recent, _ = data.Timeline(1)
+	if len(recent) != 1 {
+		t.Error(recent)
+	}
+}
+
+// Check that hour requirements describe exact and ranged hours
+func TestHoursRequirement(t *testing.T) {
+	if hours := (ReportingPeriod{ExactWorkingHours: 40}).HoursRequirement(); hours != "40 hours required" {
+		t.Error(hours)
+	}
+	if hours := (ReportingPeriod{MinWorkingHours: 32, MaxWorkingHours: 60}).HoursRequirement(); hours != "32 to 60 hours required" {
+		t.Error(hours)
+	}
+}
+
+var history = []PeriodHistory{
+	{
+		Period:    ReportingPeriod{StartDate: "2014-01-07"},
+		LateUsers: []User{User{Username: "user.two"}},
+	},
+	{
+		Period:    ReportingPeriod{StartDate: "2014-01-01"},
+		LateUsers: []User{User{Username: "user.one"}, User{Username: "user.two"}},
+	},
+}
+
+// Check that streaks stop at the most recent late period
+func TestStreak(t *testing.T) {
+	isUser := func(username string) func(user User) bool {
+		return func(user User) bool { return user.Username == username }
+	}
+	if streak := Streak(history, isUser("user.one")); streak != 1 {
+		t.Error(streak)
+	}
+	if streak := Streak(history, isUser("user.two")); streak != 0 {
+		t.Error(streak)
+	}
+	if streak := Streak(history, isUser("user.three")); streak != 2 {
+		t.Error(streak)
+	}
+}
+
+// Check that units are ranked by the share of on time timecards
+func TestUnitOnTimeRates(t *testing.T) {
+	userData := map[string]*UserData{
+		"user.one":   &UserData{Username: "user.one", Unit: "Engineering", CurrentEmployee: true},
+		"user.three": &UserData{Username: "user.three", Unit: "Engineering", CurrentEmployee: true},
+		"user.two":   &UserData{Username: "user.two", Unit: "Design", CurrentEmployee: true},
+	}
+	rates := UnitOnTimeRates(history, userData)
+	if len(rates) != 2 || rates[0].Unit != "Engineering" || rates[0].OnTime != 75 {
+		t.Error(rates)
+	}
+	if rates[1].Unit != "Design" || rates[1].OnTime != 0 {
+		t.Error(rates)
+	}
+}